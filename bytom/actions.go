@@ -0,0 +1,195 @@
+package transaction
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/bytom/bytom/common"
+	"github.com/bytom/bytom/consensus"
+	"github.com/bytom/bytom/protocol/bc"
+	"github.com/bytom/bytom/protocol/bc/types"
+	"github.com/bytom/bytom/protocol/vm/vmutil"
+
+	"github.com/vapor-sdk/util"
+)
+
+// actionDecoders maps the type field of a JSON action to its action
+var actionDecoders = map[string]func() Action{
+	"spend_utxo":      func() Action { return new(SpendUTXOAction) },
+	"control_address": func() Action { return new(ControlAddressAction) },
+	"control_program": func() Action { return new(ControlProgramAction) },
+	"retire":          func() Action { return new(RetireAction) },
+}
+
+// DecodeAction convert the JSON form of an action, selected by its type
+// field, to the action struct
+func DecodeAction(data []byte) (Action, error) {
+	var t struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+
+	newAction, ok := actionDecoders[t.Type]
+	if !ok {
+		return nil, fmt.Errorf("unsupported action type %q", t.Type)
+	}
+
+	action := newAction()
+	if err := json.Unmarshal(data, action); err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+// SpendUTXOAction spends a caller supplied unspent output
+type SpendUTXOAction struct {
+	UTXO util.UTXO `json:"utxo"`
+}
+
+// Build add the spend input
+func (a *SpendUTXOAction) Build(b *TemplateBuilder) error {
+	sourceID, err := decodeHashField("source_id", a.UTXO.SourceID)
+	if err != nil {
+		return err
+	}
+
+	assetID, amount, err := decodeAssetAmount(util.AssetAmount{AssetID: a.UTXO.AssetID, Amount: a.UTXO.Amount})
+	if err != nil {
+		return err
+	}
+
+	controlProgram, err := decodeHexField("script", a.UTXO.ControlProgram)
+	if err != nil {
+		return err
+	}
+
+	sigInst, err := buildSigningInstruction(&a.UTXO, controlProgram)
+	if err != nil {
+		return err
+	}
+
+	b.RestrictMinHeight(a.UTXO.ValidHeight)
+	return b.AddInput(types.NewSpendInput(nil, sourceID, assetID, amount, a.UTXO.SourcePosition, controlProgram), sigInst)
+}
+
+// ActionType return the type of the action
+func (a *SpendUTXOAction) ActionType() string {
+	return "spend_utxo"
+}
+
+// ControlAddressAction pays to an address
+type ControlAddressAction struct {
+	util.AssetAmount
+	Address string `json:"address"`
+}
+
+// Build add the control output
+func (a *ControlAddressAction) Build(b *TemplateBuilder) error {
+	assetID, amount, err := decodeAssetAmount(a.AssetAmount)
+	if err != nil {
+		return err
+	}
+
+	controlProgram, err := getControlProgramFromAddress(a.Address, b.netParams)
+	if err != nil {
+		return err
+	}
+	return b.AddOutput(types.NewTxOutput(assetID, amount, controlProgram))
+}
+
+// ActionType return the type of the action
+func (a *ControlAddressAction) ActionType() string {
+	return "control_address"
+}
+
+// ControlProgramAction pays to a control program
+type ControlProgramAction struct {
+	util.AssetAmount
+	ControlProgram string `json:"script"`
+}
+
+// Build add the control output
+func (a *ControlProgramAction) Build(b *TemplateBuilder) error {
+	assetID, amount, err := decodeAssetAmount(a.AssetAmount)
+	if err != nil {
+		return err
+	}
+
+	controlProgram, err := decodeHexField("script", a.ControlProgram)
+	if err != nil {
+		return err
+	}
+	if len(controlProgram) == 0 || vmutil.IsUnspendable(controlProgram) {
+		return errors.New("control output needs a spendable script")
+	}
+	return b.AddOutput(types.NewTxOutput(assetID, amount, controlProgram))
+}
+
+// ActionType return the type of the action
+func (a *ControlProgramAction) ActionType() string {
+	return "control_program"
+}
+
+// RetireAction retires an amount of asset, with an optional comment
+type RetireAction struct {
+	util.AssetAmount
+	Arbitrary string `json:"arbitrary"`
+}
+
+// Build add the retire output
+func (a *RetireAction) Build(b *TemplateBuilder) error {
+	assetID, amount, err := decodeAssetAmount(a.AssetAmount)
+	if err != nil {
+		return err
+	}
+
+	arbitrary, err := decodeHexField("arbitrary", a.Arbitrary)
+	if err != nil {
+		return err
+	}
+
+	controlProgram, err := vmutil.RetireProgram(arbitrary)
+	if err != nil {
+		return err
+	}
+	return b.AddOutput(types.NewTxOutput(assetID, amount, controlProgram))
+}
+
+// ActionType return the type of the action
+func (a *RetireAction) ActionType() string {
+	return "retire"
+}
+
+func decodeAssetAmount(assetAmount util.AssetAmount) (bc.AssetID, uint64, error) {
+	assetID, err := decodeAssetIDField(assetAmount.AssetID)
+	if err != nil {
+		return assetID, 0, err
+	}
+	if assetAmount.Amount == 0 {
+		return assetID, 0, errors.New("amount is zero")
+	}
+	return assetID, assetAmount.Amount, nil
+}
+
+// getControlProgramFromAddress returns the P2WPKH or P2WSH program of a
+// segwit address of the network
+func getControlProgramFromAddress(address string, netParams *consensus.Params) ([]byte, error) {
+	decoded, err := common.DecodeAddress(address, netParams)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q: %v", address, err)
+	}
+	if !decoded.IsForNet(netParams) {
+		return nil, fmt.Errorf("address %q is not for the %s network", address, netParams.Bech32HRPSegwit)
+	}
+
+	switch decoded.(type) {
+	case *common.AddressWitnessPubKeyHash:
+		return vmutil.P2WPKHProgram(decoded.ScriptAddress())
+	case *common.AddressWitnessScriptHash:
+		return vmutil.P2WSHProgram(decoded.ScriptAddress())
+	}
+	return nil, fmt.Errorf("unsupported address type %q", address)
+}
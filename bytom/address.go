@@ -0,0 +1,72 @@
+package transaction
+
+import (
+	"encoding/hex"
+	"errors"
+
+	"github.com/bytom/bytom/consensus"
+	"github.com/bytom/bytom/consensus/segwit"
+
+	"github.com/vapor-sdk/util"
+)
+
+// BytomDecodeAddress decode a P2WPKH or P2WSH address of the network, the
+// returned error is a *util.DecodeError
+func BytomDecodeAddress(address, network string) (*util.Address, error) {
+	netParams, err := getNetParams(network)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAddress(address, netParams)
+}
+
+// BytomValidateAddress returns nil if address is a P2WPKH or P2WSH address of
+// the network, or a *util.DecodeError telling why it is not
+func BytomValidateAddress(address, network string) error {
+	_, err := BytomDecodeAddress(address, network)
+	return err
+}
+
+// BytomProgramAddress returns the address of a hex P2WPKH or P2WSH control
+// program on the network, the returned error is a *util.DecodeError
+func BytomProgramAddress(controlProgram, network string) (*util.Address, error) {
+	netParams, err := getNetParams(network)
+	if err != nil {
+		return nil, err
+	}
+
+	prog, err := hex.DecodeString(controlProgram)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindInvalidHex, chainName, err)
+	}
+	return programAddress(prog, netParams)
+}
+
+// decodeAddress returns the util.Address of an address of the network
+func decodeAddress(address string, netParams *consensus.Params) (*util.Address, error) {
+	prog, err := getControlProgramFromAddress(address, netParams)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindInvalidAddress, chainName, err)
+	}
+	return programAddress(prog, netParams)
+}
+
+// programAddress returns the util.Address of a control program on the network
+func programAddress(prog []byte, netParams *consensus.Params) (*util.Address, error) {
+	address := getAddressFromControlProgram(prog, netParams)
+	if address == "" {
+		return nil, util.NewDecodeError(util.ErrKindInvalidAddress, chainName, errors.New("script is not a P2WPKH or P2WSH program"))
+	}
+
+	witnessProgram, err := segwit.GetHashFromStandardProg(prog)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindInvalidAddress, chainName, err)
+	}
+
+	return &util.Address{
+		Address:        address,
+		Type:           getScriptType(prog),
+		WitnessProgram: hex.EncodeToString(witnessProgram),
+		ControlProgram: hex.EncodeToString(prog),
+	}, nil
+}
@@ -0,0 +1,104 @@
+package transaction
+
+import (
+	"testing"
+
+	"github.com/vapor-sdk/util"
+)
+
+func TestBytomDecodeAddress(t *testing.T) {
+	multiSig, err := BytomMultiSig(testMultiSigKeys(t, "alice", "bob"), 1, "mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		address  string
+		network  string
+		want     *util.Address
+		wantKind util.ErrorKind
+	}{
+		{
+			address: "bm1q26kpwrrevhh2c8xrfy5vnaryu0ugc97c3j896t",
+			want: &util.Address{
+				Address:        "bm1q26kpwrrevhh2c8xrfy5vnaryu0ugc97c3j896t",
+				Type:           util.ScriptP2WPKH,
+				WitnessProgram: "56ac170c7965eeac1cc34928c9f464e3f88c17d8",
+				ControlProgram: "001456ac170c7965eeac1cc34928c9f464e3f88c17d8",
+			},
+		},
+		{
+			address: "BM1Q26KPWRREVHH2C8XRFY5VNARYU0UGC97C3J896T",
+			want: &util.Address{
+				Address:        "bm1q26kpwrrevhh2c8xrfy5vnaryu0ugc97c3j896t",
+				Type:           util.ScriptP2WPKH,
+				WitnessProgram: "56ac170c7965eeac1cc34928c9f464e3f88c17d8",
+				ControlProgram: "001456ac170c7965eeac1cc34928c9f464e3f88c17d8",
+			},
+		},
+		{
+			address: multiSig.Address,
+			want: &util.Address{
+				Address:        multiSig.Address,
+				Type:           util.ScriptP2WSH,
+				WitnessProgram: multiSig.ControlProgram[4:],
+				ControlProgram: multiSig.ControlProgram,
+			},
+		},
+		{
+			address:  "bm1q26kpwrrevhh2c8xrfy5vnaryu0ugc97c3j896t",
+			network:  "testnet",
+			wantKind: util.ErrKindInvalidAddress,
+		},
+		{
+			address:  "bm1q26kpwrrevhh2c8xrfy5vnaryu0ugc97c3j896a",
+			wantKind: util.ErrKindInvalidAddress,
+		},
+		{
+			address:  "vp1q6e3pdmarzaee09eudctnlrmlz7nmvjup8wtqxd",
+			wantKind: util.ErrKindInvalidAddress,
+		},
+		{
+			address:  "",
+			wantKind: util.ErrKindInvalidAddress,
+		},
+		{
+			address:  "bm1q26kpwrrevhh2c8xrfy5vnaryu0ugc97c3j896t",
+			network:  "nonet",
+			wantKind: util.ErrKindUnsupportedNetwork,
+		},
+	}
+
+	for i, c := range cases {
+		got, err := BytomDecodeAddress(c.address, c.network)
+		if c.wantKind != "" {
+			if decodeErr, ok := err.(*util.DecodeError); !ok || decodeErr.Kind != c.wantKind {
+				t.Errorf("case #%d, got error %v, want %s", i, err, c.wantKind)
+			}
+			if BytomValidateAddress(c.address, c.network) == nil {
+				t.Errorf("case #%d, invalid address %s is validated", i, c.address)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case #%d: %v", i, err)
+		}
+		if *got != *c.want {
+			t.Errorf("case #%d, got address %#v, want %#v", i, got, c.want)
+		}
+
+		fromProgram, err := BytomProgramAddress(c.want.ControlProgram, c.network)
+		if err != nil {
+			t.Fatalf("case #%d: %v", i, err)
+		}
+		if *fromProgram != *c.want {
+			t.Errorf("case #%d, got address %#v of program, want %#v", i, fromProgram, c.want)
+		}
+	}
+
+	for i, program := range []string{"51", "6a", "0014", "zz"} {
+		if _, err := BytomProgramAddress(program, "mainnet"); err == nil {
+			t.Errorf("case #%d, got address of program %s", i, program)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package transaction
+
+import (
+	"encoding/hex"
+	"errors"
+
+	"github.com/bytom/bytom/protocol/bc/types"
+
+	"github.com/vapor-sdk/util"
+)
+
+var errNoBlockTransactions = errors.New("block serialization carries no transactions")
+
+// BytomDecodeRawBlockWithOptions decode raw block into JSON, the returned error
+// is a *util.DecodeError
+func BytomDecodeRawBlockWithOptions(rawBlock string, mode util.BlockMode, opts util.DecodeOptions) ([]byte, error) {
+	block, err := BytomDecodeBlock(rawBlock, mode, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonBlock, err := util.MarshalBlock(block, opts)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindMarshal, chainName, err)
+	}
+	return jsonBlock, nil
+}
+
+// BytomDecodeBlock decode raw block into the annotated block, the returned
+// error is a *util.DecodeError
+func BytomDecodeBlock(rawBlock string, mode util.BlockMode, opts util.DecodeOptions) (*util.Block, error) {
+	if err := mode.Check(); err != nil {
+		return nil, util.NewDecodeError(util.ErrKindUnsupportedMode, chainName, err)
+	}
+
+	block, err := BytomParseRawBlock(rawBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	// the serialization flags tell whether transactions are present
+	serflag, _ := hex.DecodeString(rawBlock[:2])
+	if mode != util.BlockModeHeader && serflag[0] == types.SerBlockHeader {
+		return nil, util.NewDecodeError(util.ErrKindMalformedBlock, chainName, errNoBlockTransactions)
+	}
+	return BytomAnnotateBlock(block, mode, opts)
+}
+
+// BytomParseRawBlock parse raw block into the underlying types.Block, the raw
+// block may be a full block or a block header only. The returned error is a
+// *util.DecodeError
+func BytomParseRawBlock(rawBlock string) (*types.Block, error) {
+	block := &types.Block{}
+	if err := block.UnmarshalText([]byte(rawBlock)); err != nil {
+		return nil, util.NewRawBlockError(chainName, rawBlock, err)
+	}
+	return block, nil
+}
+
+// BytomAnnotateBlock build the annotated block of a parsed types.Block, the
+// returned error is a *util.DecodeError
+func BytomAnnotateBlock(block *types.Block, mode util.BlockMode, opts util.DecodeOptions) (*util.Block, error) {
+	if err := mode.Check(); err != nil {
+		return nil, util.NewDecodeError(util.ErrKindUnsupportedMode, chainName, err)
+	}
+
+	if _, err := getNetParams(opts.Network); err != nil {
+		return nil, err
+	}
+
+	annotatedBlock := &util.Block{}
+	if mode != util.BlockModeTransactions {
+		annotatedBlock.Header = buildAnnotatedBlockHeader(&block.BlockHeader)
+	}
+	if mode == util.BlockModeHeader {
+		return annotatedBlock, nil
+	}
+
+	annotatedBlock.Transactions = []*util.Transaction{}
+	for _, rawTx := range block.Transactions {
+		tx, err := BytomAnnotateTx(rawTx, opts)
+		if err != nil {
+			return nil, err
+		}
+		annotatedBlock.Transactions = append(annotatedBlock.Transactions, tx)
+	}
+	return annotatedBlock, nil
+}
+
+// buildAnnotatedBlockHeader build the annotated block header.
+func buildAnnotatedBlockHeader(header *types.BlockHeader) *util.BlockHeader {
+	blockHash := header.Hash()
+	nonce, bits := header.Nonce, header.Bits
+	return &util.BlockHeader{
+		Hash:                   blockHash.String(),
+		Version:                header.Version,
+		Height:                 header.Height,
+		PreviousBlockHash:      header.PreviousBlockHash.String(),
+		Timestamp:              header.Timestamp,
+		TransactionsMerkleRoot: header.TransactionsMerkleRoot.String(),
+		TransactionStatusHash:  header.TransactionStatusHash.String(),
+		Nonce:                  &nonce,
+		Bits:                   &bits,
+	}
+}
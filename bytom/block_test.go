@@ -0,0 +1,95 @@
+package transaction
+
+import (
+	"testing"
+
+	"github.com/bytom/bytom/config"
+
+	"github.com/vapor-sdk/util"
+)
+
+func TestBytomDecodeBlock(t *testing.T) {
+	genesisBlock := config.GenesisBlock()
+	rawBlock, err := genesisBlock.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rawHeader, err := genesisBlock.BlockHeader.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		rawBlock   string
+		mode       util.BlockMode
+		wantHeader bool
+		wantTxs    int
+		wantKind   util.ErrorKind
+	}{
+		{
+			rawBlock:   string(rawBlock),
+			mode:       util.BlockModeFull,
+			wantHeader: true,
+			wantTxs:    1,
+		},
+		{
+			rawBlock:   string(rawBlock),
+			mode:       util.BlockModeHeader,
+			wantHeader: true,
+		},
+		{
+			rawBlock: string(rawBlock),
+			mode:     util.BlockModeTransactions,
+			wantTxs:  1,
+		},
+		{
+			rawBlock:   string(rawHeader),
+			mode:       util.BlockModeHeader,
+			wantHeader: true,
+		},
+		{
+			rawBlock: string(rawHeader),
+			mode:     util.BlockModeFull,
+			wantKind: util.ErrKindMalformedBlock,
+		},
+		{
+			rawBlock: string(rawBlock[:len(rawBlock)-2]),
+			mode:     util.BlockModeFull,
+			wantKind: util.ErrKindMalformedBlock,
+		},
+		{
+			rawBlock: string(rawBlock),
+			mode:     util.BlockMode("raw"),
+			wantKind: util.ErrKindUnsupportedMode,
+		},
+	}
+
+	for i, c := range cases {
+		block, err := BytomDecodeBlock(c.rawBlock, c.mode, util.DecodeOptions{})
+		if c.wantKind != "" {
+			if decodeErr, ok := err.(*util.DecodeError); !ok || decodeErr.Kind != c.wantKind {
+				t.Errorf("case #%d, got error %v, want kind %s", i, err, c.wantKind)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case #%d, %v", i, err)
+		}
+
+		if gotHeader := block.Header != nil; gotHeader != c.wantHeader {
+			t.Errorf("case #%d, got header %t, want %t", i, gotHeader, c.wantHeader)
+		}
+		if c.wantHeader {
+			if block.Header.Hash != "a75483474799ea1aa6bb910a1a5025b4372bf20bef20f246a2c2dc5e12e8a053" || *block.Header.Nonce != 9253507043297 || *block.Header.Bits != 2161727821137910632 {
+				t.Errorf("case #%d, got header %#v", i, block.Header)
+			}
+		}
+		if len(block.Transactions) != c.wantTxs {
+			t.Errorf("case #%d, got %d transactions, want %d", i, len(block.Transactions), c.wantTxs)
+		}
+		if c.wantTxs > 0 && block.Transactions[0].Inputs[0].Type != "coinbase" {
+			t.Errorf("case #%d, got input type %s, want coinbase", i, block.Transactions[0].Inputs[0].Type)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package transaction
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/bytom/bytom/common"
+	"github.com/bytom/bytom/consensus"
+	"github.com/bytom/bytom/crypto"
+	"github.com/bytom/bytom/crypto/ed25519/chainkd"
+	"github.com/bytom/bytom/protocol/vm/vmutil"
+
+	"github.com/vapor-sdk/util"
+)
+
+// testP2WPKHKey returns a signing key and the P2WPKH program it controls
+func testP2WPKHKey(t *testing.T, seed string) (util.KeyID, []byte) {
+	xpub := chainkd.RootXPrv([]byte(seed)).XPub()
+	path := [][]byte{{0x2c, 0, 0, 0}, {0x99, 0, 0, 0}}
+	controlProgram, err := vmutil.P2WPKHProgram(crypto.Ripemd160(xpub.Derive(path).PublicKey()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return util.KeyID{XPub: xpub.String(), DerivationPath: []string{"2c000000", "99000000"}}, controlProgram
+}
+
+func TestBytomBuildTx(t *testing.T) {
+	key, controlProgram := testP2WPKHKey(t, "alice")
+	_, receiverProgram := testP2WPKHKey(t, "bob")
+	address, err := common.NewAddressWitnessPubKeyHash(receiverProgram[2:], &consensus.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	btm := consensus.BTMAssetID.String()
+	utxo := util.UTXO{
+		SourceID:       "0100000000000000000000000000000000000000000000000000000000000000",
+		SourcePosition: 1,
+		AssetID:        btm,
+		Amount:         100000000,
+		ControlProgram: hex.EncodeToString(controlProgram),
+		ValidHeight:    100,
+		Keys:           []util.KeyID{key},
+	}
+	utxoJSON, err := json.Marshal(utxo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := fmt.Sprintf(`{"time_range": 200, "actions": [
+		{"type": "spend_utxo", "utxo": %s},
+		{"type": "control_address", "asset": "%s", "amount": 60000000, "address": "%s"},
+		{"type": "retire", "asset": "%s", "amount": 30000000, "arbitrary": "6275726e"}
+	]}`, utxoJSON, btm, address.EncodeAddress(), btm)
+
+	jsonTpl, err := BytomBuildTx([]byte(request))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl := &util.Template{}
+	if err := json.Unmarshal(jsonTpl, tpl); err != nil {
+		t.Fatal(err)
+	}
+
+	if tpl.Fee != 10000000 {
+		t.Errorf("got fee %d, want 10000000", tpl.Fee)
+	}
+
+	tx, err := BytomDecodeTx(tpl.RawTransaction, util.DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tx.TimeRange != 200 || len(tx.Inputs) != 1 || len(tx.Outputs) != 2 {
+		t.Fatalf("got transaction %#v", tx)
+	}
+	if tx.Outputs[0].Address != address.EncodeAddress() || tx.Outputs[1].Type != "retire" || tx.Outputs[1].RetireComment != "6275726e" {
+		t.Errorf("got outputs %#v", tx.Outputs)
+	}
+
+	if len(tpl.SigningInstructions) != 1 {
+		t.Fatalf("got %d signing instructions, want 1", len(tpl.SigningInstructions))
+	}
+
+	sigInst := tpl.SigningInstructions[0]
+	if sigInst.SignData != tx.Inputs[0].SignData || len(sigInst.WitnessComponents) != 2 {
+		t.Fatalf("got signing instruction %#v", sigInst)
+	}
+	if wc := sigInst.WitnessComponents[0]; wc.Type != util.WitnessRawTxSignature || wc.Quorum != 1 || len(wc.Keys) != 1 || wc.Keys[0].XPub != key.XPub {
+		t.Errorf("got signature component %#v", wc)
+	}
+	if wc := sigInst.WitnessComponents[1]; wc.Type != util.WitnessData || len(wc.Value) != 64 {
+		t.Errorf("got public key component %#v", wc)
+	}
+}
+
+func TestBytomBuildInvalid(t *testing.T) {
+	key, controlProgram := testP2WPKHKey(t, "alice")
+	otherKey, _ := testP2WPKHKey(t, "bob")
+	btm := consensus.BTMAssetID.String()
+	asset := "0200000000000000000000000000000000000000000000000000000000000000"
+	spend := func(assetID string, amount uint64, validHeight uint64, keys ...util.KeyID) Action {
+		return &SpendUTXOAction{UTXO: util.UTXO{
+			SourceID:       "0100000000000000000000000000000000000000000000000000000000000000",
+			AssetID:        assetID,
+			Amount:         amount,
+			ControlProgram: hex.EncodeToString(controlProgram),
+			ValidHeight:    validHeight,
+			Keys:           keys,
+		}}
+	}
+	pay := func(assetID string, amount uint64) Action {
+		return &ControlProgramAction{AssetAmount: util.AssetAmount{AssetID: assetID, Amount: amount}, ControlProgram: hex.EncodeToString(controlProgram)}
+	}
+
+	cases := []struct {
+		actions   []Action
+		timeRange uint64
+	}{
+		{
+			actions: []Action{spend(btm, 100, 0), pay(btm, 101)},
+		},
+		{
+			actions: []Action{spend(btm, 100, 0), spend(asset, 100, 0), pay(asset, 99)},
+		},
+		{
+			actions:   []Action{spend(btm, 100, 300), pay(btm, 100)},
+			timeRange: 200,
+		},
+		{
+			actions: []Action{spend(btm, 100, 0, otherKey), pay(btm, 100)},
+		},
+		{
+			actions: []Action{spend(btm, 100, 0, key, otherKey), pay(btm, 100)},
+		},
+		{
+			actions: []Action{spend(btm, 100, 0), &ControlAddressAction{AssetAmount: util.AssetAmount{AssetID: btm, Amount: 100}, Address: "tm1q26kpwrrevhh2c8xrfy5vnaryu0ugc97c4yxp66"}},
+		},
+		{
+			actions: []Action{spend(btm, 100, 0), pay(btm, 0)},
+		},
+		{
+			actions: []Action{spend(btm, 100, 0)},
+		},
+	}
+
+	for i, c := range cases {
+		_, err := BytomBuild(c.actions, c.timeRange, "mainnet")
+		if decodeErr, ok := err.(*util.DecodeError); !ok || decodeErr.Kind != util.ErrKindInvalidTx {
+			t.Errorf("case #%d, got error %v, want invalid transaction", i, err)
+		}
+	}
+
+	if _, err := BytomBuild([]Action{spend(btm, 100, 300, key), pay(btm, 100)}, 300, "mainnet"); err != nil {
+		t.Errorf("time range at the valid height, got error %v", err)
+	}
+	if _, err := BytomBuildTx([]byte(`{"actions": [{"type": "vote_output"}]}`)); err == nil {
+		t.Error("unsupported action type built")
+	}
+}
@@ -0,0 +1,232 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/bytom/bytom/protocol/bc"
+	"github.com/bytom/bytom/protocol/bc/types"
+	"github.com/bytom/bytom/protocol/vm/vmutil"
+
+	"github.com/vapor-sdk/util"
+)
+
+// BytomEncodeRawTx encode the JSON form of an annotated transaction into raw
+// transaction hex, the returned error is a *util.DecodeError
+func BytomEncodeRawTx(jsonTx []byte) (string, error) {
+	tx := &util.Transaction{}
+	if err := json.Unmarshal(jsonTx, tx); err != nil {
+		return "", invalidTxError(err)
+	}
+
+	rawTx, err := BytomEncodeTx(tx)
+	if err != nil {
+		return "", err
+	}
+
+	rawTransaction, err := rawTx.MarshalText()
+	if err != nil {
+		return "", util.NewDecodeError(util.ErrKindMarshal, chainName, err)
+	}
+	return string(rawTransaction), nil
+}
+
+// BytomEncodeTx build the types.Tx described by an annotated transaction. Only
+// the serialized fields are read, derived fields such as the transaction ID,
+// input IDs, addresses and sign data are ignored. The returned error is a
+// *util.DecodeError
+func BytomEncodeTx(tx *util.Transaction) (*types.Tx, error) {
+	if tx.Version < 0 || tx.TimeRange < 0 {
+		return nil, invalidTxError(errors.New("negative version or time_range"))
+	}
+	if len(tx.Inputs) == 0 || len(tx.Outputs) == 0 {
+		return nil, invalidTxError(errors.New("transaction needs at least one input and one output"))
+	}
+
+	txData := types.TxData{Version: uint64(tx.Version), TimeRange: uint64(tx.TimeRange)}
+	for i := range tx.Inputs {
+		txInput, err := buildTxInput(&tx.Inputs[i])
+		if err != nil {
+			return nil, invalidTxError(fmt.Errorf("input %d: %v", i, err))
+		}
+		txData.Inputs = append(txData.Inputs, txInput)
+	}
+	for i := range tx.Outputs {
+		txOutput, err := buildTxOutput(&tx.Outputs[i])
+		if err != nil {
+			return nil, invalidTxError(fmt.Errorf("output %d: %v", i, err))
+		}
+		txData.Outputs = append(txData.Outputs, txOutput)
+	}
+	return types.NewTx(txData), nil
+}
+
+// buildTxInput build the types.TxInput of an annotated input.
+func buildTxInput(in *util.AnnotatedInput) (*types.TxInput, error) {
+	if in.Type == "coinbase" {
+		arbitrary, err := decodeHexField("arbitrary", in.Arbitrary)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewCoinbaseInput(arbitrary), nil
+	}
+
+	if in.Type != "spend" && in.Type != "issue" {
+		return nil, fmt.Errorf("unsupported input type %q", in.Type)
+	}
+
+	if in.Amount > math.MaxInt64 {
+		return nil, fmt.Errorf("amount %d exceeds the maximum of %d", in.Amount, int64(math.MaxInt64))
+	}
+
+	var arguments [][]byte
+	for _, arg := range in.WitnessArguments {
+		argument, err := decodeHexField("arguments", arg)
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, argument)
+	}
+
+	if in.Type == "issue" {
+		return buildIssuanceInput(in, arguments)
+	}
+
+	sourceID, err := decodeHashField("source_id", in.SourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	assetID, err := decodeAssetIDField(in.AssetID)
+	if err != nil {
+		return nil, err
+	}
+
+	controlProgram, err := decodeHexField("script", in.ControlProgram)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewSpendInput(arguments, sourceID, assetID, in.Amount, in.SourcePosition, controlProgram), nil
+}
+
+// buildIssuanceInput build the issue input, the asset ID is derived from the
+// issuance program and asset definition and must agree with the given one.
+func buildIssuanceInput(in *util.AnnotatedInput, arguments [][]byte) (*types.TxInput, error) {
+	nonce, err := decodeHexField("nonce", in.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	issuanceProgram, err := decodeHexField("issuance_program", in.IssuanceProgram)
+	if err != nil {
+		return nil, err
+	}
+
+	assetDefinition, err := decodeHexField("asset_definition", in.AssetDefinition)
+	if err != nil {
+		return nil, err
+	}
+
+	txInput := types.NewIssuanceInput(nonce, in.Amount, issuanceProgram, arguments, assetDefinition)
+	issuanceInput := txInput.TypedInput.(*types.IssuanceInput)
+	if in.IssuanceVMVersion != 0 {
+		issuanceInput.VMVersion = in.IssuanceVMVersion
+	}
+
+	if in.AssetID != "" {
+		assetID, err := decodeAssetIDField(in.AssetID)
+		if err != nil {
+			return nil, err
+		}
+		if assetID != issuanceInput.AssetID() {
+			return nil, errors.New("asset does not match the issuance program and asset definition")
+		}
+	}
+	return txInput, nil
+}
+
+// buildTxOutput build the types.TxOutput of an annotated output.
+func buildTxOutput(out *util.AnnotatedOutput) (*types.TxOutput, error) {
+	assetID, err := decodeAssetIDField(out.AssetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if out.Amount > math.MaxInt64 {
+		return nil, fmt.Errorf("amount %d exceeds the maximum of %d", out.Amount, int64(math.MaxInt64))
+	}
+
+	controlProgram, err := decodeHexField("script", out.ControlProgram)
+	if err != nil {
+		return nil, err
+	}
+
+	switch out.Type {
+	case "control":
+		if len(controlProgram) == 0 || vmutil.IsUnspendable(controlProgram) {
+			return nil, errors.New("control output needs a spendable script")
+		}
+
+	case "retire":
+		if controlProgram, err = buildRetireProgram(controlProgram, out.RetireComment); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported output type %q", out.Type)
+	}
+	return types.NewTxOutput(assetID, out.Amount, controlProgram), nil
+}
+
+// buildRetireProgram returns the script of a retire output. The script is
+// built from the retire comment when it is omitted, otherwise it must be
+// unspendable and agree with the comment.
+func buildRetireProgram(controlProgram []byte, retireComment string) ([]byte, error) {
+	comment, err := decodeHexField("retire_comment", retireComment)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(controlProgram) == 0 {
+		return vmutil.RetireProgram(comment)
+	}
+	if !vmutil.IsUnspendable(controlProgram) {
+		return nil, errors.New("retire output script is spendable")
+	}
+	if len(comment) != 0 && !bytes.Equal(comment, getRetireComment(controlProgram)) {
+		return nil, errors.New("retire_comment does not match the script")
+	}
+	return controlProgram, nil
+}
+
+func decodeHexField(field, value string) ([]byte, error) {
+	b, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", field, err)
+	}
+	return b, nil
+}
+
+func decodeHashField(field, value string) (bc.Hash, error) {
+	var hash bc.Hash
+	if err := hash.UnmarshalText([]byte(value)); err != nil {
+		return hash, fmt.Errorf("invalid %s %q: need 64 hex characters", field, value)
+	}
+	return hash, nil
+}
+
+func decodeAssetIDField(value string) (bc.AssetID, error) {
+	var assetID bc.AssetID
+	if err := assetID.UnmarshalText([]byte(value)); err != nil {
+		return assetID, fmt.Errorf("invalid asset %q: need 64 hex characters", value)
+	}
+	return assetID, nil
+}
+
+func invalidTxError(err error) error {
+	return util.NewDecodeError(util.ErrKindInvalidTx, chainName, err)
+}
@@ -0,0 +1,139 @@
+package transaction
+
+import (
+	"testing"
+
+	"github.com/bytom/bytom/consensus"
+	"github.com/bytom/bytom/protocol/bc"
+	"github.com/bytom/bytom/protocol/bc/types"
+	"github.com/bytom/bytom/protocol/vm/vmutil"
+	"github.com/bytom/bytom/testutil"
+
+	"github.com/vapor-sdk/util"
+)
+
+func TestBytomEncodeRawTx(t *testing.T) {
+	controlProgram := testutil.MustDecodeHexString("0014c3d320e1dc4fe787e9f13c1464e3ea5aae96a58f")
+	issuanceProgram := testutil.MustDecodeHexString("ae2054a71277cc162eb3eb21b5bd9fe54402829a53b294deaed91692a2cd8a081f9c5151ad")
+	retireProgram, err := vmutil.RetireProgram([]byte("burn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuanceInput := types.NewIssuanceInput([]byte{1, 2, 3}, 100, issuanceProgram, [][]byte{{4}}, []byte(`{"name":"TEST"}`))
+	issueTx := types.NewTx(types.TxData{
+		Version:   1,
+		TimeRange: 100,
+		Inputs: []*types.TxInput{
+			issuanceInput,
+			types.NewSpendInput([][]byte{{5}, {6}}, bc.NewHash([32]byte{1}), *consensus.BTMAssetID, 10000, 2, controlProgram),
+		},
+		Outputs: []*types.TxOutput{
+			types.NewTxOutput(issuanceInput.AssetID(), 100, controlProgram),
+			types.NewTxOutput(*consensus.BTMAssetID, 9000, retireProgram),
+		},
+	})
+	issueRawTx, err := issueTx.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	coinbaseTx := types.NewTx(types.TxData{
+		Version: 1,
+		Inputs:  []*types.TxInput{types.NewCoinbaseInput([]byte("arbitrary"))},
+		Outputs: []*types.TxOutput{types.NewTxOutput(*consensus.BTMAssetID, 41250000000, controlProgram)},
+	})
+	coinbaseRawTx, err := coinbaseTx.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []string{
+		`070100010161015fc8215913a270d3d953ef431626b19a89adf38e2486bb235da732f0afed515299ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff8099c4d59901000116001456ac170c7965eeac1cc34928c9f464e3f88c17d8630240b1e99a3590d7db80126b273088937a87ba1e8d2f91021a2fd2c36579f7713926e8c7b46c047a43933b008ff16ecc2eb8ee888b4ca1fe3fdf082824e0b3899b02202fb851c6ed665fcd9ebc259da1461a1e284ac3b27f5e86c84164aa518648222602013effffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff80bbd0ec980101160014c3d320e1dc4fe787e9f13c1464e3ea5aae96a58f00013cffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff8084af5f01160014bb93cdb4eca74b068321eeb84ac5d33686281b6500`,
+		string(issueRawTx),
+		string(coinbaseRawTx),
+	}
+
+	for i, rawTransaction := range cases {
+		// the JSON of the decoder round trips with numbers and string amounts
+		for _, opts := range []util.DecodeOptions{{}, {StringAmounts: true}} {
+			jsonTx, err := BytomDecodeRawTxWithOptions(rawTransaction, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gotRawTransaction, err := BytomEncodeRawTx(jsonTx)
+			if err != nil {
+				t.Fatalf("case #%d, string amounts %t, encode error: %v", i, opts.StringAmounts, err)
+			}
+
+			if gotRawTransaction != rawTransaction {
+				t.Errorf("case #%d, string amounts %t, got raw transaction %s, want %s", i, opts.StringAmounts, gotRawTransaction, rawTransaction)
+			}
+		}
+	}
+}
+
+func TestBytomEncodeTxInvalid(t *testing.T) {
+	btm := consensus.BTMAssetID.String()
+	sourceID := "0100000000000000000000000000000000000000000000000000000000000000"
+	spend := util.AnnotatedInput{Type: "spend", SourceID: sourceID, AssetID: btm, Amount: 100, ControlProgram: "0014c3d320e1dc4fe787e9f13c1464e3ea5aae96a58f"}
+	control := util.AnnotatedOutput{Type: "control", AssetID: btm, Amount: 100, ControlProgram: "0014c3d320e1dc4fe787e9f13c1464e3ea5aae96a58f"}
+	cases := []struct {
+		input  util.AnnotatedInput
+		output util.AnnotatedOutput
+	}{
+		{
+			input:  util.AnnotatedInput{Type: "veto", SourceID: sourceID, AssetID: btm},
+			output: control,
+		},
+		{
+			input:  util.AnnotatedInput{Type: "spend", SourceID: "01", AssetID: btm},
+			output: control,
+		},
+		{
+			input:  util.AnnotatedInput{Type: "spend", SourceID: sourceID, AssetID: btm, Amount: 1 << 63},
+			output: control,
+		},
+		{
+			input:  util.AnnotatedInput{Type: "issue", AssetID: btm, IssuanceProgram: "51"},
+			output: control,
+		},
+		{
+			input:  spend,
+			output: util.AnnotatedOutput{Type: "vote", AssetID: btm, ControlProgram: "51"},
+		},
+		{
+			input:  spend,
+			output: util.AnnotatedOutput{Type: "control", AssetID: btm, ControlProgram: "6a"},
+		},
+		{
+			input:  spend,
+			output: util.AnnotatedOutput{Type: "retire", AssetID: btm, ControlProgram: "51"},
+		},
+		{
+			input:  spend,
+			output: util.AnnotatedOutput{Type: "retire", AssetID: btm, ControlProgram: "6a", RetireComment: "0g"},
+		},
+	}
+
+	for i, c := range cases {
+		tx := &util.Transaction{
+			Version: 1,
+			Inputs:  []util.AnnotatedInput{c.input},
+			Outputs: []util.AnnotatedOutput{c.output},
+		}
+		_, err := BytomEncodeTx(tx)
+		if decodeErr, ok := err.(*util.DecodeError); !ok || decodeErr.Kind != util.ErrKindInvalidTx {
+			t.Errorf("case #%d, got error %v, want invalid transaction", i, err)
+		}
+	}
+
+	if _, err := BytomEncodeTx(&util.Transaction{Version: 1, Inputs: []util.AnnotatedInput{spend}}); err == nil {
+		t.Error("transaction without outputs encoded")
+	}
+
+	if _, err := BytomEncodeRawTx([]byte(`{"version": 1, "inputs": [{"type": "spend", "amount": "1.5"}]}`)); err == nil {
+		t.Error("transaction with a fractional string amount encoded")
+	}
+}
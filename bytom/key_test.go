@@ -0,0 +1,150 @@
+package transaction
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bytom/bytom/consensus"
+	"github.com/bytom/bytom/crypto/ed25519/chainkd"
+
+	"github.com/vapor-sdk/util"
+)
+
+func TestBytomDerive(t *testing.T) {
+	root, err := util.SeedKey([]byte("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := chainkd.RootXPrv([]byte("alice")).XPub().String(); root.XPub != want {
+		t.Fatalf("got root xpub %s, want %s", root.XPub, want)
+	}
+
+	key, controlProgram := testP2WPKHKey(t, "alice")
+	accountPath := util.AccountPath(1, true, 2)
+	keys, err := BytomDerive(*root, []string{"m/44/153", accountPath, "m/44'/153'/0/0/1"}, "mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(keys[0].Key, key) || keys[0].ControlProgram != hex.EncodeToString(controlProgram) {
+		t.Errorf("got key %v with script %s, want %v with script %x", keys[0].Key, keys[0].ControlProgram, key, controlProgram)
+	}
+	if !strings.HasPrefix(keys[0].Address, "bm1q") {
+		t.Errorf("got address %s, want a mainnet p2wpkh address", keys[0].Address)
+	}
+	if want := []string{"2c000000", "99000000", "01000000", "01000000", "02000000"}; accountPath != "m/44/153/1/1/2" || !reflect.DeepEqual(keys[1].Key.DerivationPath, want) {
+		t.Errorf("got path %s of derivation path %v, want %v", accountPath, keys[1].Key.DerivationPath, want)
+	}
+
+	// the xpub alone derives the same non-hardened keys
+	public, err := BytomDerive(util.Key{XPub: root.XPub}, []string{accountPath}, "mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if public[0].XPrv != "" || public[0].SigningKey != nil {
+		t.Errorf("got private key from an xpub")
+	}
+	if public[0].XPub != keys[1].XPub || public[0].ControlProgram != keys[1].ControlProgram || !reflect.DeepEqual(public[0].Key, keys[1].Key) {
+		t.Errorf("got %v from the xpub, want %v", public[0], keys[1])
+	}
+
+	// the key of a hardened path is rooted at the last hardened step
+	hardened := keys[2]
+	account, err := BytomDerive(*root, []string{"m/44h/153h"}, "mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hardened.Key.XPub != account[0].XPub || !reflect.DeepEqual(hardened.Key.DerivationPath, []string{"00000000", "00000000", "01000000"}) {
+		t.Errorf("got key %v, want xpub %s", hardened.Key, account[0].XPub)
+	}
+	if hardened.SigningKey.XPrv != account[0].XPrv {
+		t.Errorf("got signing key %s, want %s", hardened.SigningKey.XPrv, account[0].XPrv)
+	}
+
+	btm := consensus.BTMAssetID.String()
+	tpl, err := BytomBuild([]Action{
+		&SpendUTXOAction{UTXO: util.UTXO{
+			SourceID:       "0100000000000000000000000000000000000000000000000000000000000000",
+			AssetID:        btm,
+			Amount:         100000000,
+			ControlProgram: hardened.ControlProgram,
+			Keys:           []util.KeyID{hardened.Key},
+		}},
+		&ControlAddressAction{AssetAmount: util.AssetAmount{AssetID: btm, Amount: 90000000}, Address: keys[1].Address},
+	}, 0, "mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	xprvs, _, err := util.DecodeXPrvKeys([]util.XPrvKey{*hardened.SigningKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := BytomSignTemplate(tpl, util.XPrvSignFunc(xprvs...)); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := BytomParseRawTx(tpl.RawTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := BytomValidateTx(tx, util.ValidateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid {
+		t.Errorf("spend of the hardened key is not valid: %s", result.Error)
+	}
+}
+
+func TestBytomDeriveKeysError(t *testing.T) {
+	root, err := util.SeedKey([]byte("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := util.SeedKey([]byte("bob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		desc string
+		req  util.DeriveRequest
+	}{
+		{
+			desc: "hardened step from an xpub",
+			req:  util.DeriveRequest{Key: util.Key{XPub: root.XPub}, Paths: []string{"m/44'/153"}},
+		},
+		{
+			desc: "invalid path step",
+			req:  util.DeriveRequest{Key: *root, Paths: []string{"m/44/coin"}},
+		},
+		{
+			desc: "index out of range",
+			req:  util.DeriveRequest{Key: *root, Paths: []string{"m/4294967296"}},
+		},
+		{
+			desc: "xpub of another xprv",
+			req:  util.DeriveRequest{Key: util.Key{XPrv: root.XPrv, XPub: other.XPub}, Paths: []string{"m"}},
+		},
+		{
+			desc: "invalid xpub",
+			req:  util.DeriveRequest{Key: util.Key{XPub: "00"}, Paths: []string{"m"}},
+		},
+	}
+
+	for _, c := range cases {
+		jsonRequest, err := json.Marshal(c.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = BytomDeriveKeys(jsonRequest)
+		decodeErr, ok := err.(*util.DecodeError)
+		if !ok || decodeErr.Kind != util.ErrKindInvalidKey {
+			t.Errorf("%s: got error %v, want kind %s", c.desc, err, util.ErrKindInvalidKey)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/bytom/bytom/consensus/segwit"
+	"github.com/bytom/bytom/crypto"
+	"github.com/bytom/bytom/protocol/bc/types"
+	"github.com/bytom/bytom/protocol/vm/vmutil"
+
+	"github.com/vapor-sdk/util"
+)
+
+// BytomCreateMultiSig create an m-of-n multisig program from the JSON form of
+// a util.MultiSigRequest and return a util.MultiSig as JSON, the returned
+// error is a *util.DecodeError
+func BytomCreateMultiSig(jsonRequest []byte) ([]byte, error) {
+	req := &util.MultiSigRequest{}
+	if err := json.Unmarshal(jsonRequest, req); err != nil {
+		return nil, invalidTxError(err)
+	}
+
+	multiSig, err := BytomMultiSig(req.Keys, req.Quorum, req.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonMultiSig, err := json.Marshal(multiSig)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindMarshal, chainName, err)
+	}
+	return jsonMultiSig, nil
+}
+
+// BytomMultiSig create the redeem program, P2WSH program and address of a
+// quorum-of-len(keys) multisig. The keys are sorted by xpub like the Bytom
+// wallet does, so the order of the cosigners does not matter. The returned
+// error is a *util.DecodeError
+func BytomMultiSig(keys []util.KeyID, quorum int, network string) (*util.MultiSig, error) {
+	netParams, err := getNetParams(network)
+	if err != nil {
+		return nil, err
+	}
+
+	sortedKeys, err := util.SortKeyIDs(keys)
+	if err != nil {
+		return nil, invalidTxError(err)
+	}
+
+	redeemProgram, err := multiSigProgram(sortedKeys, quorum)
+	if err != nil {
+		return nil, invalidTxError(err)
+	}
+
+	scriptHash := crypto.Sha256(redeemProgram)
+	controlProgram, err := vmutil.P2WSHProgram(scriptHash)
+	if err != nil {
+		return nil, invalidTxError(err)
+	}
+
+	return &util.MultiSig{
+		RedeemProgram:  hex.EncodeToString(redeemProgram),
+		ControlProgram: hex.EncodeToString(controlProgram),
+		Address:        buildP2SHAddress(scriptHash, netParams),
+		Quorum:         quorum,
+		Keys:           sortedKeys,
+	}, nil
+}
+
+// BytomCombineTx combine the partially signed templates of the JSON form of a
+// util.CombineRequest and return a util.SignResult as JSON, the returned error
+// is a *util.DecodeError
+func BytomCombineTx(jsonRequest []byte) ([]byte, error) {
+	req := &util.CombineRequest{}
+	if err := json.Unmarshal(jsonRequest, req); err != nil {
+		return nil, invalidTxError(err)
+	}
+
+	tpl, err := BytomCombineTemplates(req.Templates...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &util.SignResult{RawTransaction: tpl.RawTransaction, Template: tpl, SignComplete: util.SignProgress(tpl)}
+	jsonResult, err := json.Marshal(result)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindMarshal, chainName, err)
+	}
+	return jsonResult, nil
+}
+
+// BytomCombineTemplates merge the signatures of partially signed copies of the
+// same transaction template, and return the template whose raw transaction has
+// the witness arguments of every fully signed input. The returned error is a
+// *util.DecodeError
+func BytomCombineTemplates(tpls ...*util.Template) (*util.Template, error) {
+	var txs []*types.Tx
+	for i, tpl := range tpls {
+		tx, err := BytomParseRawTx(tpl.RawTransaction)
+		if err != nil {
+			return nil, err
+		}
+		if len(txs) > 0 && tx.ID != txs[0].ID {
+			return nil, util.NewDecodeError(util.ErrKindSign, chainName, fmt.Errorf("template %d is a different transaction", i))
+		}
+		txs = append(txs, tx)
+	}
+	if len(txs) == 0 {
+		return nil, util.NewDecodeError(util.ErrKindSign, chainName, errors.New("no template to combine"))
+	}
+
+	tpl, err := util.CombineTemplates(sigHashFunc(txs[0]), tpls...)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindSign, chainName, err)
+	}
+
+	if _, err := materializeTemplate(tpl); err != nil {
+		return nil, err
+	}
+	return tpl, nil
+}
+
+// multiSigProgram returns the redeem program checking quorum signatures of the
+// public keys derived from keys, in the given order
+func multiSigProgram(keys []util.KeyID, quorum int) ([]byte, error) {
+	pubKeys, err := util.MultiSigPublicKeys(keys, quorum)
+	if err != nil {
+		return nil, err
+	}
+	return vmutil.P2SPMultiSigProgram(pubKeys, quorum)
+}
+
+// buildMultiSigInstruction returns the instruction to sign a spend of a P2WSH
+// multisig utxo, quorum raw transaction signatures followed by the redeem
+// program.
+func buildMultiSigInstruction(utxo *util.UTXO, controlProgram []byte) (*util.SigningInstruction, error) {
+	redeemProgram, err := multiSigProgram(utxo.Keys, utxo.Quorum)
+	if err != nil {
+		return nil, err
+	}
+
+	scriptHash, err := segwit.GetHashFromStandardProg(controlProgram)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(crypto.Sha256(redeemProgram), scriptHash) {
+		return nil, errors.New("signing keys and quorum do not match the script")
+	}
+
+	return &util.SigningInstruction{WitnessComponents: []*util.WitnessComponent{
+		{Type: util.WitnessRawTxSignature, Quorum: utxo.Quorum, Keys: utxo.Keys},
+		{Type: util.WitnessData, Value: hex.EncodeToString(redeemProgram)},
+	}}, nil
+}
@@ -0,0 +1,167 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bytom/bytom/consensus"
+	"github.com/bytom/bytom/consensus/segwit"
+	"github.com/bytom/bytom/crypto/ed25519/chainkd"
+
+	"github.com/vapor-sdk/util"
+)
+
+// testMultiSigKeys returns the signing keys of seeds
+func testMultiSigKeys(t *testing.T, seeds ...string) []util.KeyID {
+	var keys []util.KeyID
+	for _, seed := range seeds {
+		key, _ := testP2WPKHKey(t, seed)
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestBytomMultiSig(t *testing.T) {
+	multiSig, err := BytomMultiSig(testMultiSigKeys(t, "alice", "bob", "carol"), 2, "mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	controlProgram, err := hex.DecodeString(multiSig.ControlProgram)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !segwit.IsP2WSHScript(controlProgram) || !strings.HasPrefix(multiSig.Address, "bm1q") || multiSig.Quorum != 2 || len(multiSig.Keys) != 3 {
+		t.Errorf("got multisig %#v", multiSig)
+	}
+
+	reordered, err := BytomMultiSig(testMultiSigKeys(t, "carol", "alice", "bob"), 2, "mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reordered.Address != multiSig.Address || reordered.RedeemProgram != multiSig.RedeemProgram {
+		t.Errorf("got address %s for reordered keys, want %s", reordered.Address, multiSig.Address)
+	}
+
+	cases := []struct {
+		keys   []util.KeyID
+		quorum int
+	}{
+		{keys: testMultiSigKeys(t, "alice", "bob"), quorum: 0},
+		{keys: testMultiSigKeys(t, "alice", "bob"), quorum: 3},
+		{keys: testMultiSigKeys(t, "alice", "alice"), quorum: 1},
+		{keys: []util.KeyID{{XPub: "00"}}, quorum: 1},
+		{quorum: 1},
+	}
+
+	for i, c := range cases {
+		_, err := BytomMultiSig(c.keys, c.quorum, "mainnet")
+		if decodeErr, ok := err.(*util.DecodeError); !ok || decodeErr.Kind != util.ErrKindInvalidTx {
+			t.Errorf("case #%d, got error %v, want invalid transaction", i, err)
+		}
+	}
+}
+
+func TestBytomMultiSigSignAndCombine(t *testing.T) {
+	multiSig, err := BytomMultiSig(testMultiSigKeys(t, "alice", "bob", "carol"), 2, "mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	btm := consensus.BTMAssetID.String()
+	tpl, err := BytomBuild([]Action{
+		&SpendUTXOAction{UTXO: util.UTXO{
+			SourceID:       "0100000000000000000000000000000000000000000000000000000000000000",
+			AssetID:        btm,
+			Amount:         100000000,
+			ControlProgram: multiSig.ControlProgram,
+			Keys:           multiSig.Keys,
+			Quorum:         multiSig.Quorum,
+		}},
+		&ControlAddressAction{AssetAmount: util.AssetAmount{AssetID: btm, Amount: 90000000}, Address: multiSig.Address},
+	}, 0, "mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jsonTpl, err := json.Marshal(tpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var partials []*util.Template
+	for _, seed := range []string{"carol", "alice"} {
+		partial := &util.Template{}
+		if err := json.Unmarshal(jsonTpl, partial); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := BytomSignTemplate(partial, util.XPrvSignFunc(chainkd.RootXPrv([]byte(seed)))); err != nil {
+			t.Fatal(err)
+		}
+		if util.SignProgress(partial) || partial.RawTransaction != tpl.RawTransaction {
+			t.Fatalf("template signed by %s is complete", seed)
+		}
+		partials = append(partials, partial)
+	}
+
+	combined, err := BytomCombineTemplates(partials...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !util.SignProgress(combined) {
+		t.Fatalf("got incomplete template %#v", combined.SigningInstructions[0].WitnessComponents[0])
+	}
+
+	tx, err := BytomParseRawTx(combined.RawTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redeemProgram, err := hex.DecodeString(multiSig.RedeemProgram)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := tx.SigHash(0).Byte32()
+	args := tx.Inputs[0].Arguments()
+	if len(args) != 3 || !bytes.Equal(args[2], redeemProgram) {
+		t.Fatalf("got witness arguments %x", args)
+	}
+
+	var signed []string
+	for _, seed := range []string{"alice", "bob", "carol"} {
+		xpub := chainkd.RootXPrv([]byte(seed)).XPub().Derive([][]byte{{0x2c, 0, 0, 0}, {0x99, 0, 0, 0}})
+		for _, arg := range args[:2] {
+			if xpub.Verify(hash[:], arg) {
+				signed = append(signed, seed)
+			}
+		}
+	}
+	if len(signed) != 2 || signed[0] == "bob" || signed[1] == "bob" {
+		t.Errorf("got signatures of %v, want alice and carol", signed)
+	}
+
+	result, err := BytomValidateTx(tx, util.ValidateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid {
+		t.Errorf("combined transaction is not valid: %s %#v", result.Error, result.Inputs)
+	}
+
+	forged := &util.Template{}
+	if err := json.Unmarshal(jsonTpl, forged); err != nil {
+		t.Fatal(err)
+	}
+	forged.SigningInstructions[0].SignData = "0300000000000000000000000000000000000000000000000000000000000000"
+	if _, err := BytomCombineTemplates(partials[0], forged); err == nil || !strings.Contains(err.Error(), "sign_data") {
+		t.Errorf("template with a forged sign_data: got error %v", err)
+	}
+
+	other := testSignTemplate(t, "alice")
+	if _, err := BytomCombineTemplates(partials[0], other); err == nil {
+		t.Error("templates of different transactions are combined")
+	}
+}
@@ -0,0 +1,33 @@
+package transaction
+
+import (
+	"fmt"
+
+	"github.com/bytom/bytom/consensus"
+
+	"github.com/vapor-sdk/util"
+)
+
+const defaultNetwork = "mainnet"
+
+// networkAliases maps the network names shared with vapor onto bytom chain IDs
+var networkAliases = map[string]string{
+	"testnet": "wisdom",
+}
+
+// getNetParams returns the consensus params of the bytom network, the network
+// must be one of the chain IDs in consensus.NetParams or "testnet"
+func getNetParams(network string) (*consensus.Params, error) {
+	if network == "" {
+		network = defaultNetwork
+	}
+	if chainID, ok := networkAliases[network]; ok {
+		network = chainID
+	}
+
+	params, ok := consensus.NetParams[network]
+	if !ok {
+		return nil, util.NewDecodeError(util.ErrKindUnsupportedNetwork, chainName, fmt.Errorf("unsupported network %q", network))
+	}
+	return &params, nil
+}
@@ -0,0 +1,240 @@
+package transaction
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/golang/groupcache/lru"
+
+	"github.com/bytom/bytom/consensus/difficulty"
+	"github.com/bytom/bytom/crypto/scrypt"
+	"github.com/bytom/bytom/crypto/sha3pool"
+	"github.com/bytom/bytom/mining/tensority"
+	"github.com/bytom/bytom/protocol/bc"
+	"github.com/bytom/bytom/protocol/bc/types"
+
+	"github.com/vapor-sdk/util"
+)
+
+// the tensority matrices, matNum matrices of matSize x matSize int8
+const (
+	matSize = 1 << 8
+	matNum  = 1 << 8
+)
+
+// maxSeedCached is the number of seeds whose matrices are kept, the matrices
+// of a seed take 16MB and a seed lasts for 256 blocks
+const maxSeedCached = 2
+
+var (
+	seedCacheMu sync.Mutex
+	seedCache   = lru.New(maxSeedCached)
+
+	// powMu guards tensority.AIHash, the result cache shared with
+	// difficulty.CheckProofOfWork which is not safe for concurrent use. The
+	// SDK only reaches it through VerifyBytomHeaderPoW.
+	powMu sync.Mutex
+)
+
+// VerifyBytomHeaderPoW reports whether the tensority hash of header with seed
+// meets the target of header.Bits. The seed is the hash of the last block at a
+// positive multiple of 256 height up to the parent of header, or
+// consensus.InitialSeed for the first 257 blocks. The matrices of the seed are
+// cached so that the headers of an epoch only pay for them once, the hash is
+// then put in the result cache of tensority for difficulty.CheckProofOfWork.
+func VerifyBytomHeaderPoW(header *types.BlockHeader, seed *bc.Hash) bool {
+	hash := header.Hash()
+	result := tensorityHash(&hash, seed)
+
+	powMu.Lock()
+	defer powMu.Unlock()
+
+	tensority.AIHash.AddCache(&hash, seed, result)
+	return difficulty.CheckProofOfWork(&hash, seed, header.Bits)
+}
+
+// BytomVerifyRawBlockPoW verify the proof of work of a raw block or block
+// header with the hex seed, see VerifyBytomHeaderPoW. The returned error is a
+// *util.DecodeError
+func BytomVerifyRawBlockPoW(rawBlock, seed string) (bool, error) {
+	block, err := BytomParseRawBlock(rawBlock)
+	if err != nil {
+		return false, err
+	}
+
+	seedHash, err := decodeHashField("seed", seed)
+	if err != nil {
+		return false, util.NewDecodeError(util.ErrKindInvalidHex, chainName, err)
+	}
+	return VerifyBytomHeaderPoW(&block.BlockHeader, &seedHash), nil
+}
+
+// tensorityHash is go_algorithm.LegacyAlgorithm with the matrices of seed
+// cached
+func tensorityHash(hash, seed *bc.Hash) *bc.Hash {
+	return hashMatrix(mulMatrix(hash.Bytes(), seedMatrices(seed)))
+}
+
+// seedMatrices returns the matNum matrices of seed, row after row
+func seedMatrices(seed *bc.Hash) []int8 {
+	seedCacheMu.Lock()
+	defer seedCacheMu.Unlock()
+
+	if matrices, ok := seedCache.Get(*seed); ok {
+		return matrices.([]int8)
+	}
+
+	matrices := calcSeedMatrices(seed.Bytes())
+	seedCache.Add(*seed, matrices)
+	return matrices
+}
+
+// calcSeedMatrices expands seed with scrypt into the matrices, every 64
+// words of the expansion give a row of two matrices
+func calcSeedMatrices(seed []byte) []int8 {
+	extSeed := make([]byte, 32*4)
+	copy(extSeed, seed)
+	for i := 0; i < 3; i++ {
+		var h [32]byte
+		sha3pool.Sum256(h[:], extSeed[i*32:(i+1)*32])
+		copy(extSeed[(i+1)*32:(i+2)*32], h[:])
+	}
+
+	cache := make([]uint32, 0, 128*32*1024)
+	v := make([]uint32, 32*1024)
+	for i := 0; i < 128; i++ {
+		scrypt.Smix(extSeed, v)
+		cache = append(cache, v...)
+	}
+
+	words := make([]uint32, matNum*matSize*matSize/4)
+	for i := 0; i < 128; i++ {
+		start := i * 1024 * 32
+		for j := 0; j < 512; j++ {
+			copy(words[start+j*32:start+j*32+32], cache[start+j*64:start+j*64+32])
+			copy(words[start+512*32+j*32:start+512*32+j*32+32], cache[start+j*64+32:start+j*64+64])
+		}
+	}
+
+	matrices := make([]int8, matNum*matSize*matSize)
+	for i, w := range words {
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], w)
+		for j := range b {
+			matrices[i*4+j] = int8(b[j])
+		}
+	}
+	return matrices
+}
+
+// mulMatrix multiplies the matrices chosen by the header hash in 4 lanes,
+// folding every product back to int8, and sums the lanes
+func mulMatrix(headerHash []byte, matrices []int8) []uint8 {
+	var lanes [4][]int8
+	var wg sync.WaitGroup
+	for lane := range lanes {
+		wg.Add(1)
+		go func(lane int) {
+			defer wg.Done()
+
+			var sequence [32]byte
+			sha3pool.Sum256(sequence[:], headerHash[lane*8:(lane+1)*8])
+
+			ma := make([]int8, matSize*matSize)
+			for i := 0; i < matSize; i++ {
+				ma[i*matSize+i] = 1
+			}
+			mc := make([]int8, matSize*matSize)
+			for round := 0; round < 2; round++ {
+				for _, index := range sequence {
+					mb := matrices[int(index)*matSize*matSize : (int(index)+1)*matSize*matSize]
+					mulTransposed(mc, ma, mb)
+					ma, mc = mc, ma
+				}
+			}
+			lanes[lane] = ma
+		}(lane)
+	}
+	wg.Wait()
+
+	result := make([]uint8, matSize*matSize)
+	for i := range result {
+		var sum int32
+		for _, lane := range lanes {
+			sum += int32(lane[i])
+		}
+		result[i] = uint8(int8(sum & 0xff))
+	}
+	return result
+}
+
+// mulTransposed sets mc to ma times the transpose of mb, each element folded
+// to int8 by adding its two low bytes. Four columns are computed at once to
+// share the loads of the row.
+func mulTransposed(mc, ma, mb []int8) {
+	var row [matSize]int32
+	for i := 0; i < matSize; i++ {
+		for k := range row {
+			row[k] = int32(ma[i*matSize+k])
+		}
+		for j := 0; j < matSize; j += 4 {
+			c0 := mb[j*matSize : (j+1)*matSize]
+			c1 := mb[(j+1)*matSize : (j+2)*matSize]
+			c2 := mb[(j+2)*matSize : (j+3)*matSize]
+			c3 := mb[(j+3)*matSize : (j+4)*matSize]
+			_, _, _, _ = c0[matSize-1], c1[matSize-1], c2[matSize-1], c3[matSize-1]
+			var v0, v1, v2, v3 int32
+			for k := 0; k < matSize; k++ {
+				a := row[k]
+				v0 += a * int32(c0[k])
+				v1 += a * int32(c1[k])
+				v2 += a * int32(c2[k])
+				v3 += a * int32(c3[k])
+			}
+			mc[i*matSize+j] = foldInt8(v0)
+			mc[i*matSize+j+1] = foldInt8(v1)
+			mc[i*matSize+j+2] = foldInt8(v2)
+			mc[i*matSize+j+3] = foldInt8(v3)
+		}
+	}
+}
+
+// foldInt8 adds the two low bytes of v, truncated to int8
+func foldInt8(v int32) int8 {
+	return int8((v & 0xff) + ((v >> 8) & 0xff))
+}
+
+// hashMatrix folds the result of mulMatrix with fnv and hashes it
+func hashMatrix(result []uint8) *bc.Hash {
+	var mat32 [matSize][matSize / 4]uint32
+	for i := 0; i < matSize; i++ {
+		for j := 0; j < matSize/4; j++ {
+			mat32[i][j] = uint32(result[i*matSize+j+192])<<24 |
+				uint32(result[i*matSize+j+128])<<16 |
+				uint32(result[i*matSize+j+64])<<8 |
+				uint32(result[i*matSize+j])
+		}
+	}
+
+	for k := matSize; k > 1; k = k / 2 {
+		for j := 0; j < k/2; j++ {
+			for i := 0; i < matSize/4; i++ {
+				mat32[j][i] = fnv(mat32[j][i], mat32[j+k/2][i])
+			}
+		}
+	}
+
+	data := make([]byte, matSize)
+	for i := 0; i < matSize/4; i++ {
+		binary.LittleEndian.PutUint32(data[i*4:], mat32[0][i])
+	}
+
+	var h [32]byte
+	sha3pool.Sum256(h[:], data)
+	hash := bc.NewHash(h)
+	return &hash
+}
+
+func fnv(a, b uint32) uint32 {
+	return a*0x01000193 ^ b
+}
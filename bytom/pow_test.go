@@ -0,0 +1,98 @@
+package transaction
+
+import (
+	"testing"
+
+	"github.com/bytom/bytom/config"
+	"github.com/bytom/bytom/consensus"
+	"github.com/bytom/bytom/protocol/bc"
+	"github.com/bytom/bytom/protocol/bc/types"
+)
+
+func TestTensorityHash(t *testing.T) {
+	if testing.Short() {
+		t.Skip("tensority is slow")
+	}
+
+	genesis := config.GenesisBlock()
+	genesisHash := genesis.Hash()
+
+	// the results of go_algorithm.LegacyAlgorithm
+	cases := []struct {
+		hash   bc.Hash
+		seed   bc.Hash
+		result string
+	}{
+		{
+			hash:   genesisHash,
+			seed:   *consensus.InitialSeed,
+			result: "3a9d805453ab6d5304b933764d29066e8cf963abf04718cc81fecd6278000000",
+		},
+		{
+			hash:   bc.NewHash([32]byte{1, 2, 3, 4, 5, 6, 7, 8, 9}),
+			seed:   genesisHash,
+			result: "5480408dedb268f11ec2a3e8180f17b658d4bd1aa88bec75abcf061ba927fd5c",
+		},
+		{
+			hash:   bc.NewHash([32]byte{0xff, 0xee, 0xdd}),
+			seed:   *consensus.InitialSeed,
+			result: "8bf7cd125ac44123e86bb9178c8eee7eeb79737bbcb8c512a28545de1654295c",
+		},
+		{
+			hash:   bc.Hash{},
+			seed:   bc.Hash{},
+			result: "c5d4a19ce842fee409696d14e483f9efe4a7ecc036d1cfeba0199f13f14dc90f",
+		},
+	}
+
+	for _, c := range cases {
+		if got := tensorityHash(&c.hash, &c.seed); got.String() != c.result {
+			t.Errorf("hash %x seed %x: got %x, want %s", c.hash.Bytes(), c.seed.Bytes(), got.Bytes(), c.result)
+		}
+		if _, ok := seedCache.Get(c.seed); !ok {
+			t.Errorf("matrices of seed %x are not cached", c.seed.Bytes())
+		}
+	}
+}
+
+func TestVerifyBytomHeaderPoW(t *testing.T) {
+	if testing.Short() {
+		t.Skip("tensority is slow")
+	}
+
+	genesis := config.GenesisBlock().BlockHeader
+	rawHeader, err := genesis.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if valid, err := BytomVerifyRawBlockPoW(string(rawHeader), consensus.InitialSeed.String()); err != nil || !valid {
+		t.Errorf("proof of work of the genesis block is not valid: %v", err)
+	}
+
+	cases := []struct {
+		desc   string
+		header func() *types.BlockHeader
+		seed   *bc.Hash
+	}{
+		{
+			desc:   "another seed",
+			header: func() *types.BlockHeader { return &genesis },
+			seed:   &bc.Hash{V0: 1},
+		},
+		{
+			desc: "another nonce",
+			header: func() *types.BlockHeader {
+				header := genesis
+				header.Nonce++
+				return &header
+			},
+			seed: consensus.InitialSeed,
+		},
+	}
+
+	for _, c := range cases {
+		if VerifyBytomHeaderPoW(c.header(), c.seed) {
+			t.Errorf("%s: got valid proof of work", c.desc)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package transaction
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/bytom/bytom/consensus/segwit"
+	"github.com/bytom/bytom/protocol/bc/types"
+
+	"github.com/vapor-sdk/util"
+)
+
+// BytomSignTx sign the template or raw transaction of the JSON form of a
+// util.SignRequest and return a util.SignResult as JSON, the returned error is
+// a *util.DecodeError
+func BytomSignTx(jsonRequest []byte) ([]byte, error) {
+	req := &util.SignRequest{}
+	if err := json.Unmarshal(jsonRequest, req); err != nil {
+		return nil, invalidTxError(err)
+	}
+
+	xprvs, keys, err := util.DecodeXPrvKeys(req.Keys)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindSign, chainName, err)
+	}
+
+	signFn := util.XPrvSignFunc(xprvs...)
+	result := &util.SignResult{}
+	if req.Template != nil {
+		if result.RawTransaction, err = BytomSignTemplate(req.Template, signFn); err != nil {
+			return nil, err
+		}
+		result.Template = req.Template
+		result.SignComplete = util.SignProgress(req.Template)
+	} else {
+		if result.RawTransaction, result.SignComplete, err = BytomSignRawTx(req.RawTransaction, keys, signFn); err != nil {
+			return nil, err
+		}
+	}
+
+	jsonResult, err := json.Marshal(result)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindMarshal, chainName, err)
+	}
+	return jsonResult, nil
+}
+
+// BytomSignTemplate fill the signatures of tpl with signFn and return the raw
+// transaction with the witness arguments of every fully signed input, tpl
+// keeps the collected signatures. The returned error is a *util.DecodeError
+func BytomSignTemplate(tpl *util.Template, signFn util.SignFunc) (string, error) {
+	tx, err := BytomParseRawTx(tpl.RawTransaction)
+	if err != nil {
+		return "", err
+	}
+
+	if err := util.SignTemplate(tpl, sigHashFunc(tx), signFn); err != nil {
+		return "", util.NewDecodeError(util.ErrKindSign, chainName, err)
+	}
+	return materializeTemplate(tpl)
+}
+
+// sigHashFunc returns the util.SigHashFunc of the inputs of tx
+func sigHashFunc(tx *types.Tx) util.SigHashFunc {
+	return func(position uint32) ([32]byte, error) {
+		if int(position) >= len(tx.Inputs) {
+			return [32]byte{}, fmt.Errorf("signing instruction references missing input %d", position)
+		}
+		return tx.SigHash(position).Byte32(), nil
+	}
+}
+
+// BytomSignRawTx sign every P2WPKH input of a raw transaction whose script is
+// controlled by one of keys with signFn, the witness arguments become the
+// signature and the public key. Other inputs are left untouched, the returned
+// bool reports whether every matched input is signed. The returned error is a
+// *util.DecodeError
+func BytomSignRawTx(rawTransaction string, keys []util.KeyID, signFn util.SignFunc) (string, bool, error) {
+	tx, err := BytomParseRawTx(rawTransaction)
+	if err != nil {
+		return "", false, err
+	}
+
+	tpl := &util.Template{RawTransaction: rawTransaction}
+	for i, in := range tx.Inputs {
+		if in.InputType() != types.SpendInputType {
+			continue
+		}
+
+		sigInst := findSigningInstruction(in.ControlProgram(), keys)
+		if sigInst == nil {
+			continue
+		}
+
+		signData := tx.SigHash(uint32(i))
+		sigInst.Position = uint32(i)
+		sigInst.SignData = signData.String()
+		tpl.SigningInstructions = append(tpl.SigningInstructions, sigInst)
+	}
+
+	signedTx, err := BytomSignTemplate(tpl, signFn)
+	if err != nil {
+		return "", false, err
+	}
+	return signedTx, util.SignProgress(tpl), nil
+}
+
+// findSigningInstruction returns the signing instruction of the first key
+// which controls the P2WPKH controlProgram, or nil if there is none
+func findSigningInstruction(controlProgram []byte, keys []util.KeyID) *util.SigningInstruction {
+	if !segwit.IsP2WPKHScript(controlProgram) {
+		return nil
+	}
+
+	for _, key := range keys {
+		utxo := &util.UTXO{Keys: []util.KeyID{key}}
+		if sigInst, err := buildSigningInstruction(utxo, controlProgram); err == nil {
+			return sigInst
+		}
+	}
+	return nil
+}
+
+// materializeTemplate set the witness arguments of every fully signed input of
+// tpl and return the raw transaction, which also replaces tpl.RawTransaction
+func materializeTemplate(tpl *util.Template) (string, error) {
+	tx, err := BytomParseRawTx(tpl.RawTransaction)
+	if err != nil {
+		return "", err
+	}
+
+	for _, sigInst := range tpl.SigningInstructions {
+		if int(sigInst.Position) >= len(tx.Inputs) {
+			return "", invalidTxError(fmt.Errorf("signing instruction references missing input %d", sigInst.Position))
+		}
+
+		args, ok, err := sigInst.WitnessArguments()
+		if err != nil {
+			return "", util.NewDecodeError(util.ErrKindSign, chainName, fmt.Errorf("input %d: %v", sigInst.Position, err))
+		}
+		if ok {
+			tx.SetInputArguments(sigInst.Position, args)
+		}
+	}
+
+	rawTransaction, err := tx.MarshalText()
+	if err != nil {
+		return "", util.NewDecodeError(util.ErrKindMarshal, chainName, err)
+	}
+	tpl.RawTransaction = string(rawTransaction)
+	return tpl.RawTransaction, nil
+}
@@ -0,0 +1,226 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/bytom/bytom/consensus"
+	"github.com/bytom/bytom/crypto/ed25519/chainkd"
+
+	"github.com/vapor-sdk/util"
+)
+
+// testSignTemplate returns an unsigned template which spends two utxos, only
+// the first one is controlled by the key of seed
+func testSignTemplate(t *testing.T, seed string) *util.Template {
+	key, controlProgram := testP2WPKHKey(t, seed)
+	_, otherProgram := testP2WPKHKey(t, "bob")
+	btm := consensus.BTMAssetID.String()
+	tpl, err := BytomBuild([]Action{
+		&SpendUTXOAction{UTXO: util.UTXO{
+			SourceID:       "0100000000000000000000000000000000000000000000000000000000000000",
+			AssetID:        btm,
+			Amount:         100000000,
+			ControlProgram: hex.EncodeToString(controlProgram),
+			Keys:           []util.KeyID{key},
+		}},
+		&SpendUTXOAction{UTXO: util.UTXO{
+			SourceID:       "0200000000000000000000000000000000000000000000000000000000000000",
+			AssetID:        btm,
+			Amount:         100000000,
+			ControlProgram: hex.EncodeToString(otherProgram),
+		}},
+		&ControlProgramAction{AssetAmount: util.AssetAmount{AssetID: btm, Amount: 190000000}, ControlProgram: hex.EncodeToString(otherProgram)},
+	}, 0, "mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tpl
+}
+
+// checkP2WPKHWitness checks that the witness arguments of input position are a
+// signature of signData by the key of seed followed by its public key
+func checkP2WPKHWitness(t *testing.T, rawTransaction string, position int, signData string, seed string) {
+	tx, err := BytomParseRawTx(rawTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	xpub := chainkd.RootXPrv([]byte(seed)).XPub().Derive([][]byte{{0x2c, 0, 0, 0}, {0x99, 0, 0, 0}})
+	hash, err := hex.DecodeString(signData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := tx.Inputs[position].Arguments()
+	if len(args) != 2 || !bytes.Equal(args[1], xpub.PublicKey()) || !xpub.Verify(hash, args[0]) {
+		t.Errorf("input #%d, got witness arguments %x", position, args)
+	}
+}
+
+func TestBytomSignTemplate(t *testing.T) {
+	tpl := testSignTemplate(t, "alice")
+	signData := tpl.SigningInstructions[0].SignData
+	rawTransaction, err := BytomSignTemplate(tpl, util.XPrvSignFunc(chainkd.RootXPrv([]byte("alice"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rawTransaction != tpl.RawTransaction || !util.SignProgress(tpl) {
+		t.Errorf("got template %#v", tpl)
+	}
+	checkP2WPKHWitness(t, rawTransaction, 0, signData, "alice")
+
+	tx, err := BytomParseRawTx(rawTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args := tx.Inputs[1].Arguments(); len(args) != 0 {
+		t.Errorf("input #1, got witness arguments %x, want none", args)
+	}
+
+	result, err := BytomValidateTx(tx, util.ValidateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Inputs[0].Status != util.ValidationPass || result.Inputs[1].Status != util.ValidationFail {
+		t.Errorf("got input validations %#v, want the signed input to pass", result.Inputs)
+	}
+}
+
+func TestBytomSignTemplateUnknownKey(t *testing.T) {
+	tpl := testSignTemplate(t, "alice")
+	unsignedTx := tpl.RawTransaction
+	var calls int
+	signFn := func(xpub chainkd.XPub, path [][]byte, hash [32]byte) ([]byte, error) {
+		calls++
+		return nil, util.ErrUnknownKey
+	}
+
+	rawTransaction, err := BytomSignTemplate(tpl, signFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 || rawTransaction != unsignedTx || util.SignProgress(tpl) {
+		t.Errorf("got %d calls and raw transaction %s", calls, rawTransaction)
+	}
+
+	signFn = func(xpub chainkd.XPub, path [][]byte, hash [32]byte) ([]byte, error) {
+		return nil, fmt.Errorf("signer is offline")
+	}
+	if _, err := BytomSignTemplate(tpl, signFn); err == nil {
+		t.Error("signer error is ignored")
+	} else if decodeErr, ok := err.(*util.DecodeError); !ok || decodeErr.Kind != util.ErrKindSign {
+		t.Errorf("got error %v, want sign error", err)
+	}
+}
+
+func TestBytomSignTemplateForgedSignData(t *testing.T) {
+	forged := "0300000000000000000000000000000000000000000000000000000000000000"
+	cases := []struct {
+		desc   string
+		tamper func(tpl *util.Template)
+	}{
+		{
+			desc:   "forged sign_data",
+			tamper: func(tpl *util.Template) { tpl.SigningInstructions[0].SignData = forged },
+		},
+		{
+			desc: "sign_data of another input",
+			tamper: func(tpl *util.Template) {
+				other := testSignTemplate(t, "bob")
+				tpl.SigningInstructions[0].SignData = other.SigningInstructions[0].SignData
+			},
+		},
+		{
+			desc:   "position of another input",
+			tamper: func(tpl *util.Template) { tpl.SigningInstructions[0].Position = 1 },
+		},
+		{
+			desc:   "position out of range",
+			tamper: func(tpl *util.Template) { tpl.SigningInstructions[0].Position = 2 },
+		},
+	}
+
+	for _, c := range cases {
+		tpl := testSignTemplate(t, "alice")
+		c.tamper(tpl)
+
+		var calls int
+		signFn := func(xpub chainkd.XPub, path [][]byte, hash [32]byte) ([]byte, error) {
+			calls++
+			return chainkd.RootXPrv([]byte("alice")).Derive(path).Sign(hash[:]), nil
+		}
+		_, err := BytomSignTemplate(tpl, signFn)
+		if decodeErr, ok := err.(*util.DecodeError); !ok || decodeErr.Kind != util.ErrKindSign {
+			t.Errorf("%s: got error %v, want sign error", c.desc, err)
+		}
+		if calls != 0 {
+			t.Errorf("%s: signer called %d times", c.desc, calls)
+		}
+	}
+}
+
+func TestBytomSignRawTx(t *testing.T) {
+	tpl := testSignTemplate(t, "alice")
+	key, _ := testP2WPKHKey(t, "alice")
+	otherKey, _ := testP2WPKHKey(t, "bob")
+	rawTransaction, complete, err := BytomSignRawTx(tpl.RawTransaction, []util.KeyID{otherKey, key}, util.XPrvSignFunc(chainkd.RootXPrv([]byte("alice"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if complete {
+		t.Error("input of bob is reported as signed")
+	}
+	checkP2WPKHWitness(t, rawTransaction, 0, tpl.SigningInstructions[0].SignData, "alice")
+	if _, _, err := BytomSignRawTx("00", []util.KeyID{key}, util.XPrvSignFunc()); err == nil {
+		t.Error("invalid raw transaction is signed")
+	}
+}
+
+func TestBytomSignTx(t *testing.T) {
+	tpl := testSignTemplate(t, "alice")
+	xprv := chainkd.RootXPrv([]byte("alice"))
+	keys := fmt.Sprintf(`[{"xprv": "%s", "derivation_path": ["2c000000", "99000000"]}]`, xprv.String())
+	jsonTpl, err := json.Marshal(tpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		request      string
+		wantTemplate bool
+	}{
+		{
+			request:      fmt.Sprintf(`{"template": %s, "keys": %s}`, jsonTpl, keys),
+			wantTemplate: true,
+		},
+		{
+			request: fmt.Sprintf(`{"raw_transaction": "%s", "keys": %s}`, tpl.RawTransaction, keys),
+		},
+	}
+
+	for i, c := range cases {
+		jsonResult, err := BytomSignTx([]byte(c.request))
+		if err != nil {
+			t.Fatalf("case #%d, got error %v", i, err)
+		}
+
+		result := &util.SignResult{}
+		if err := json.Unmarshal(jsonResult, result); err != nil {
+			t.Fatal(err)
+		}
+		if (result.Template != nil) != c.wantTemplate || !result.SignComplete {
+			t.Errorf("case #%d, got result %s", i, jsonResult)
+		}
+		checkP2WPKHWitness(t, result.RawTransaction, 0, tpl.SigningInstructions[0].SignData, "alice")
+	}
+
+	if _, err := BytomSignTx([]byte(`{"raw_transaction": "00", "keys": [{"xprv": "00"}]}`)); err == nil {
+		t.Error("invalid xprv is accepted")
+	}
+}
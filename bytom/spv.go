@@ -0,0 +1,318 @@
+package transaction
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/bytom/bytom/consensus"
+	"github.com/bytom/bytom/consensus/difficulty"
+	"github.com/bytom/bytom/protocol/bc"
+	"github.com/bytom/bytom/protocol/bc/types"
+	"github.com/bytom/bytom/protocol/state"
+
+	"github.com/vapor-sdk/util"
+)
+
+var (
+	errEmptyCheckpoint   = errors.New("checkpoint has no header")
+	errMissingSeed       = errors.New("seed of the checkpoint is required")
+	errOrphanHeader      = errors.New("previous block is not known")
+	errBadVersion        = errors.New("block version is not 1")
+	errMisorderedHeight  = errors.New("block height is not the height of the previous block plus one")
+	errBadBits           = errors.New("bits do not match the difficulty retarget")
+	errBadTimestamp      = errors.New("timestamp is not after the median time of the last blocks or too far in the future")
+	errBadWorkProof      = errors.New("proof of work does not meet the target of the bits")
+	errMissingRetarget   = errors.New("block at the start of the retarget epoch is not known")
+	errMissingMedianTime = errors.New("not enough previous blocks for the median time")
+)
+
+// headerNode is a checked header in the header chain, the header is kept
+// because the state.BlockNode of the first checkpoint header can not rebuild
+// its previous block hash
+type headerNode struct {
+	*state.BlockNode
+	header *types.BlockHeader
+}
+
+// BytomHeaderChain follows the Bytom header chain from a trusted checkpoint
+// like an SPV client. Each header added is checked against its parent with
+// the header rules of a node: version, height, previous block hash, the bits
+// of the difficulty retarget, the median time and the proof of work. Headers
+// may fork from any known header, the best header is the tip of the chain with
+// the most accumulated work, the first one seen on a tie.
+type BytomHeaderChain struct {
+	mu    sync.RWMutex
+	index map[bc.Hash]*headerNode
+	best  *headerNode
+
+	// checkPoW is the proof of work check, replaced by the tests
+	checkPoW func(header *types.BlockHeader, seed *bc.Hash) bool
+}
+
+// NewBytomHeaderChain returns the header chain of the checkpoint, consecutive
+// headers which are trusted without checks, seed is the seed of the first of
+// them and may be nil for the genesis block. To check every later header the
+// checkpoint should start at a multiple of consensus.BlocksPerRetarget height
+// and hold at least consensus.MedianTimeBlocks headers.
+func NewBytomHeaderChain(checkpoint []*types.BlockHeader, seed *bc.Hash) (*BytomHeaderChain, error) {
+	if len(checkpoint) == 0 {
+		return nil, errEmptyCheckpoint
+	}
+
+	first := checkpoint[0]
+	node := &state.BlockNode{
+		Hash:                   first.Hash(),
+		Seed:                   seed,
+		WorkSum:                difficulty.CalcWork(first.Bits),
+		Version:                first.Version,
+		Height:                 first.Height,
+		Timestamp:              first.Timestamp,
+		Nonce:                  first.Nonce,
+		Bits:                   first.Bits,
+		TransactionsMerkleRoot: first.TransactionsMerkleRoot,
+		TransactionStatusHash:  first.TransactionStatusHash,
+	}
+	if first.Height == 0 {
+		node.Seed = consensus.InitialSeed
+	} else if seed == nil {
+		return nil, errMissingSeed
+	}
+
+	root := &headerNode{BlockNode: node, header: first}
+	c := &BytomHeaderChain{
+		index:    map[bc.Hash]*headerNode{node.Hash: root},
+		best:     root,
+		checkPoW: VerifyBytomHeaderPoW,
+	}
+	for i, header := range checkpoint[1:] {
+		if header.Height != c.best.Height+1 || header.PreviousBlockHash != c.best.Hash {
+			return nil, fmt.Errorf("checkpoint header %d does not follow the previous one", i+1)
+		}
+		node, err := c.addNode(header, c.best)
+		if err != nil {
+			return nil, err
+		}
+		c.best = node
+	}
+	return c, nil
+}
+
+// AddHeader checks header against its parent and adds it to the chain, the
+// best header moves to it when its chain has more work. Known headers are
+// ignored.
+func (c *BytomHeaderChain) AddHeader(header *types.BlockHeader) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.index[header.Hash()]; ok {
+		return nil
+	}
+
+	parent, ok := c.index[header.PreviousBlockHash]
+	if !ok {
+		return errOrphanHeader
+	}
+	if err := c.checkHeader(header, parent); err != nil {
+		return err
+	}
+
+	node, err := c.addNode(header, parent)
+	if err != nil {
+		return err
+	}
+	if node.WorkSum.Cmp(c.best.WorkSum) > 0 {
+		c.best = node
+	}
+	return nil
+}
+
+// BestHeader returns the tip of the chain with the most work
+func (c *BytomHeaderChain) BestHeader() *types.BlockHeader {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.best.header
+}
+
+// Header returns the known header of hash, nil when it is not known
+func (c *BytomHeaderChain) Header(hash *bc.Hash) *types.BlockHeader {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if node, ok := c.index[*hash]; ok {
+		return node.header
+	}
+	return nil
+}
+
+// WorkSum returns the work of the chain from the first checkpoint header to
+// the header of hash, nil when it is not known
+func (c *BytomHeaderChain) WorkSum(hash *bc.Hash) *big.Int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if node, ok := c.index[*hash]; ok {
+		return new(big.Int).Set(node.WorkSum)
+	}
+	return nil
+}
+
+// addNode adds header on top of parent, state.NewBlockNode sums the work and
+// picks the seed
+func (c *BytomHeaderChain) addNode(header *types.BlockHeader, parent *headerNode) (*headerNode, error) {
+	node, err := state.NewBlockNode(header, parent.BlockNode)
+	if err != nil {
+		return nil, err
+	}
+
+	hn := &headerNode{BlockNode: node, header: header}
+	c.index[node.Hash] = hn
+	return hn, nil
+}
+
+// checkHeader is validation.ValidateBlockHeader for a header, except that the
+// ancestors needed by the retarget and the median time must be known
+func (c *BytomHeaderChain) checkHeader(header *types.BlockHeader, parent *headerNode) error {
+	if header.Version != 1 {
+		return errBadVersion
+	}
+	if header.Height != parent.Height+1 {
+		return errMisorderedHeight
+	}
+
+	bits, err := c.nextBits(parent.BlockNode)
+	if err != nil {
+		return err
+	}
+	if header.Bits != bits {
+		return errBadBits
+	}
+
+	if err := c.checkTimestamp(header, parent.BlockNode); err != nil {
+		return err
+	}
+	if !c.checkPoW(header, parent.CalcNextSeed()) {
+		return errBadWorkProof
+	}
+	return nil
+}
+
+// nextBits is state.BlockNode.CalcNextBits, retargeting with
+// difficulty.CalcNextRequiredDifficulty at the end of an epoch
+func (c *BytomHeaderChain) nextBits(parent *state.BlockNode) (uint64, error) {
+	if parent.Height%consensus.BlocksPerRetarget != 0 || parent.Height == 0 {
+		return parent.Bits, nil
+	}
+
+	compareNode := parent.Parent
+	for compareNode != nil && compareNode.Height%consensus.BlocksPerRetarget != 0 {
+		compareNode = compareNode.Parent
+	}
+	if compareNode == nil {
+		return 0, errMissingRetarget
+	}
+	return difficulty.CalcNextRequiredDifficulty(parent.BlockHeader(), compareNode.BlockHeader()), nil
+}
+
+// checkTimestamp requires the timestamp to be after the median time of the
+// last consensus.MedianTimeBlocks blocks and not too far in the future
+func (c *BytomHeaderChain) checkTimestamp(header *types.BlockHeader, parent *state.BlockNode) error {
+	count, node := 1, parent
+	for ; count < consensus.MedianTimeBlocks && node.Parent != nil; count++ {
+		node = node.Parent
+	}
+	if count < consensus.MedianTimeBlocks && node.Height != 0 {
+		return errMissingMedianTime
+	}
+
+	if header.Timestamp > uint64(time.Now().Unix())+consensus.MaxTimeOffsetSeconds {
+		return errBadTimestamp
+	}
+	if header.Timestamp <= parent.CalcPastMedianTime() {
+		return errBadTimestamp
+	}
+	return nil
+}
+
+// BytomVerifyHeaders follow the header chain of the JSON form of a
+// util.HeaderChainRequest and return the util.HeaderChainResult as JSON. A
+// rejected header is reported in its status, the returned error is a
+// *util.DecodeError
+func BytomVerifyHeaders(jsonRequest []byte) ([]byte, error) {
+	req := &util.HeaderChainRequest{}
+	if err := json.Unmarshal(jsonRequest, req); err != nil {
+		return nil, util.NewDecodeError(util.ErrKindInvalidHeader, chainName, err)
+	}
+
+	result, err := BytomVerifyHeaderChain(req)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonResult, err := json.Marshal(result)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindMarshal, chainName, err)
+	}
+	return jsonResult, nil
+}
+
+// BytomVerifyHeaderChain follow the header chain of req from its checkpoint,
+// see BytomHeaderChain. The returned error is a *util.DecodeError
+func BytomVerifyHeaderChain(req *util.HeaderChainRequest) (*util.HeaderChainResult, error) {
+	checkpoint, err := parseRawHeaders(req.Checkpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var seed *bc.Hash
+	if req.Seed != "" {
+		seedHash, err := decodeHashField("seed", req.Seed)
+		if err != nil {
+			return nil, util.NewDecodeError(util.ErrKindInvalidHex, chainName, err)
+		}
+		seed = &seedHash
+	}
+
+	headers, err := parseRawHeaders(req.Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	chain, err := NewBytomHeaderChain(checkpoint, seed)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindInvalidHeader, chainName, err)
+	}
+
+	result := &util.HeaderChainResult{Headers: []util.HeaderStatus{}}
+	for _, header := range headers {
+		hash := header.Hash()
+		status := util.HeaderStatus{Hash: hash.String(), Height: header.Height, Valid: true}
+		if err := chain.AddHeader(header); err != nil {
+			status.Valid, status.Error = false, err.Error()
+		}
+		result.Headers = append(result.Headers, status)
+	}
+
+	best := chain.BestHeader()
+	bestHash := best.Hash()
+	result.BestHash = bestHash.String()
+	result.BestHeight = best.Height
+	result.WorkSum = chain.WorkSum(&bestHash).String()
+	return result, nil
+}
+
+// parseRawHeaders parse raw blocks or block headers into their headers
+func parseRawHeaders(rawHeaders []string) ([]*types.BlockHeader, error) {
+	var headers []*types.BlockHeader
+	for _, rawHeader := range rawHeaders {
+		block, err := BytomParseRawBlock(rawHeader)
+		if err != nil {
+			return nil, err
+		}
+		headers = append(headers, &block.BlockHeader)
+	}
+	return headers, nil
+}
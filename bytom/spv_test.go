@@ -0,0 +1,330 @@
+package transaction
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/bytom/bytom/config"
+	"github.com/bytom/bytom/consensus"
+	"github.com/bytom/bytom/consensus/difficulty"
+	"github.com/bytom/bytom/protocol/bc"
+	"github.com/bytom/bytom/protocol/bc/types"
+
+	"github.com/vapor-sdk/util"
+)
+
+// badNonce fails the proof of work of testHeaderChain
+const badNonce = 1
+
+func nextTestHeader(parent *types.BlockHeader, timestamp, bits uint64) *types.BlockHeader {
+	return &types.BlockHeader{
+		Version:           1,
+		Height:            parent.Height + 1,
+		PreviousBlockHash: parent.Hash(),
+		Timestamp:         timestamp,
+		Bits:              bits,
+	}
+}
+
+// testHeaderChain returns the chain of checkpoint with a proof of work check
+// which records the seeds
+func testHeaderChain(t *testing.T, checkpoint []*types.BlockHeader, seed *bc.Hash, seeds map[uint64]bc.Hash) *BytomHeaderChain {
+	chain, err := NewBytomHeaderChain(checkpoint, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain.checkPoW = func(header *types.BlockHeader, seed *bc.Hash) bool {
+		if seeds != nil {
+			seeds[header.Height] = *seed
+		}
+		return header.Nonce != badNonce
+	}
+	return chain
+}
+
+func TestBytomHeaderChainRetarget(t *testing.T) {
+	genesis := config.GenesisBlock().BlockHeader
+	seeds := make(map[uint64]bc.Hash)
+	chain := testHeaderChain(t, []*types.BlockHeader{&genesis}, nil, seeds)
+
+	// blocks twice as fast as the target
+	interval := consensus.TargetSecondsPerBlock / 2
+	headers := []*types.BlockHeader{&genesis}
+	for height := uint64(1); height <= consensus.BlocksPerRetarget; height++ {
+		header := nextTestHeader(headers[height-1], genesis.Timestamp+height*interval, genesis.Bits)
+		if err := chain.AddHeader(header); err != nil {
+			t.Fatalf("header %d: %v", height, err)
+		}
+		headers = append(headers, header)
+	}
+
+	for height, want := range map[uint64]bc.Hash{
+		1:   *consensus.InitialSeed,
+		256: *consensus.InitialSeed,
+		257: headers[256].Hash(),
+		513: headers[512].Hash(),
+		768: headers[512].Hash(),
+	} {
+		if got := seeds[height]; got != want {
+			t.Errorf("seed of header %d: got %x, want %x", height, got.Bytes(), want.Bytes())
+		}
+	}
+
+	last := headers[consensus.BlocksPerRetarget]
+	timestamp := last.Timestamp + interval
+	if err := chain.AddHeader(nextTestHeader(last, timestamp, last.Bits)); err != errBadBits {
+		t.Errorf("header without retarget: got error %v, want %v", err, errBadBits)
+	}
+
+	bits := difficulty.CalcNextRequiredDifficulty(last, &genesis)
+	if difficulty.CompactToBig(bits).Cmp(difficulty.CompactToBig(genesis.Bits)) >= 0 {
+		t.Fatalf("retarget of fast blocks does not lower the target")
+	}
+
+	header := nextTestHeader(last, timestamp, bits)
+	if err := chain.AddHeader(header); err != nil {
+		t.Fatalf("retargeted header: %v", err)
+	}
+	if got := chain.BestHeader(); got != header {
+		t.Errorf("best header: got height %d, want %d", got.Height, header.Height)
+	}
+
+	hash := header.Hash()
+	want := new(big.Int).Mul(difficulty.CalcWork(genesis.Bits), big.NewInt(int64(consensus.BlocksPerRetarget+1)))
+	want.Add(want, difficulty.CalcWork(bits))
+	if got := chain.WorkSum(&hash); got.Cmp(want) != 0 {
+		t.Errorf("work sum: got %v, want %v", got, want)
+	}
+}
+
+func TestBytomHeaderChainReject(t *testing.T) {
+	genesis := config.GenesisBlock().BlockHeader
+	headers := []*types.BlockHeader{&genesis}
+	for height := uint64(1); height <= 12; height++ {
+		headers = append(headers, nextTestHeader(headers[height-1], genesis.Timestamp+height*consensus.TargetSecondsPerBlock, genesis.Bits))
+	}
+	tip := headers[12]
+
+	cases := []struct {
+		desc   string
+		header func() *types.BlockHeader
+		err    error
+	}{
+		{
+			desc: "unknown previous block",
+			header: func() *types.BlockHeader {
+				header := nextTestHeader(tip, tip.Timestamp+1, tip.Bits)
+				header.PreviousBlockHash = bc.Hash{V0: 1}
+				return header
+			},
+			err: errOrphanHeader,
+		},
+		{
+			desc: "version",
+			header: func() *types.BlockHeader {
+				header := nextTestHeader(tip, tip.Timestamp+1, tip.Bits)
+				header.Version = 2
+				return header
+			},
+			err: errBadVersion,
+		},
+		{
+			desc: "height",
+			header: func() *types.BlockHeader {
+				header := nextTestHeader(tip, tip.Timestamp+1, tip.Bits)
+				header.Height++
+				return header
+			},
+			err: errMisorderedHeight,
+		},
+		{
+			desc:   "bits within an epoch",
+			header: func() *types.BlockHeader { return nextTestHeader(tip, tip.Timestamp+1, tip.Bits-1) },
+			err:    errBadBits,
+		},
+		{
+			desc:   "timestamp at the median time",
+			header: func() *types.BlockHeader { return nextTestHeader(tip, headers[7].Timestamp, tip.Bits) },
+			err:    errBadTimestamp,
+		},
+		{
+			desc:   "timestamp after the median time",
+			header: func() *types.BlockHeader { return nextTestHeader(tip, headers[7].Timestamp+1, tip.Bits) },
+		},
+		{
+			desc: "timestamp in the future",
+			header: func() *types.BlockHeader {
+				future := uint64(time.Now().Unix()) + consensus.MaxTimeOffsetSeconds + 60
+				return nextTestHeader(tip, future, tip.Bits)
+			},
+			err: errBadTimestamp,
+		},
+		{
+			desc: "proof of work",
+			header: func() *types.BlockHeader {
+				header := nextTestHeader(tip, tip.Timestamp+1, tip.Bits)
+				header.Nonce = badNonce
+				return header
+			},
+			err: errBadWorkProof,
+		},
+	}
+
+	for _, c := range cases {
+		chain := testHeaderChain(t, headers, nil, nil)
+		header := c.header()
+		if err := chain.AddHeader(header); err != c.err {
+			t.Errorf("%s: got error %v, want %v", c.desc, err, c.err)
+		}
+
+		hash := header.Hash()
+		if known := chain.Header(&hash) != nil; known != (c.err == nil) {
+			t.Errorf("%s: header known %t", c.desc, known)
+		}
+		if c.err != nil && chain.BestHeader() != tip {
+			t.Errorf("%s: best header moved to a rejected header", c.desc)
+		}
+	}
+}
+
+func TestBytomHeaderChainFork(t *testing.T) {
+	genesis := config.GenesisBlock().BlockHeader
+	chain := testHeaderChain(t, []*types.BlockHeader{&genesis}, nil, nil)
+
+	extend := func(parent *types.BlockHeader, n int, nonce uint64) []*types.BlockHeader {
+		var headers []*types.BlockHeader
+		for i := 0; i < n; i++ {
+			header := nextTestHeader(parent, parent.Timestamp+consensus.TargetSecondsPerBlock, parent.Bits)
+			header.Nonce = nonce
+			if err := chain.AddHeader(header); err != nil {
+				t.Fatalf("header %d: %v", header.Height, err)
+			}
+			headers = append(headers, header)
+			parent = header
+		}
+		return headers
+	}
+
+	main := extend(&genesis, 5, 10)
+	forkA := extend(main[1], 3, 20)
+	if got := chain.BestHeader(); got != main[4] {
+		t.Errorf("fork of the same work: got best height %d nonce %d, want the first seen tip", got.Height, got.Nonce)
+	}
+
+	forkA = append(forkA, extend(forkA[2], 1, 20)...)
+	if got := chain.BestHeader(); got != forkA[3] {
+		t.Errorf("heavier fork: got best height %d nonce %d, want height %d nonce 20", got.Height, got.Nonce, forkA[3].Height)
+	}
+
+	if err := chain.AddHeader(main[2]); err != nil || chain.BestHeader() != forkA[3] {
+		t.Errorf("known header: got error %v, best height %d", err, chain.BestHeader().Height)
+	}
+}
+
+func TestNewBytomHeaderChain(t *testing.T) {
+	start := &types.BlockHeader{Version: 1, Height: 2 * consensus.BlocksPerRetarget, Timestamp: 1600000000, Bits: config.GenesisBlock().Bits}
+	checkpoint := []*types.BlockHeader{start}
+	for i := 1; i < consensus.MedianTimeBlocks; i++ {
+		parent := checkpoint[i-1]
+		checkpoint = append(checkpoint, nextTestHeader(parent, parent.Timestamp+consensus.TargetSecondsPerBlock, parent.Bits))
+	}
+	tip := checkpoint[len(checkpoint)-1]
+
+	seed := bc.Hash{V0: 7}
+	seeds := make(map[uint64]bc.Hash)
+	chain := testHeaderChain(t, checkpoint, &seed, seeds)
+	header := nextTestHeader(tip, tip.Timestamp+1, tip.Bits)
+	if err := chain.AddHeader(header); err != nil {
+		t.Fatalf("header after the checkpoint: %v", err)
+	}
+	if seeds[header.Height] != seed {
+		t.Errorf("seed: got %x, want the seed of the checkpoint", seeds[header.Height].Bytes())
+	}
+
+	short := testHeaderChain(t, checkpoint[1:], &seed, nil)
+	if err := short.AddHeader(header); err != errMissingMedianTime {
+		t.Errorf("short checkpoint: got error %v, want %v", err, errMissingMedianTime)
+	}
+
+	end := &types.BlockHeader{Version: 1, Height: 3*consensus.BlocksPerRetarget - uint64(len(checkpoint)) + 1, Timestamp: 1600000000, Bits: start.Bits}
+	epochEnd := []*types.BlockHeader{end}
+	for i := 1; i < len(checkpoint); i++ {
+		parent := epochEnd[i-1]
+		epochEnd = append(epochEnd, nextTestHeader(parent, parent.Timestamp+consensus.TargetSecondsPerBlock, parent.Bits))
+	}
+	last := epochEnd[len(epochEnd)-1]
+	chain = testHeaderChain(t, epochEnd, &seed, nil)
+	if err := chain.AddHeader(nextTestHeader(last, last.Timestamp+1, last.Bits)); err != errMissingRetarget {
+		t.Errorf("retarget without the epoch start: got error %v, want %v", err, errMissingRetarget)
+	}
+
+	if _, err := NewBytomHeaderChain(checkpoint, nil); err != errMissingSeed {
+		t.Errorf("no seed: got error %v, want %v", err, errMissingSeed)
+	}
+	if _, err := NewBytomHeaderChain(nil, &seed); err != errEmptyCheckpoint {
+		t.Errorf("no checkpoint: got error %v, want %v", err, errEmptyCheckpoint)
+	}
+	if _, err := NewBytomHeaderChain([]*types.BlockHeader{start, header}, &seed); err == nil {
+		t.Errorf("checkpoint with a gap: got no error")
+	}
+}
+
+func TestBytomVerifyHeaders(t *testing.T) {
+	genesis := config.GenesisBlock().BlockHeader
+	rawHeader := func(header *types.BlockHeader) string {
+		raw, err := header.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(raw)
+	}
+
+	orphan := nextTestHeader(&genesis, genesis.Timestamp+1, genesis.Bits)
+	orphan.PreviousBlockHash = bc.Hash{V0: 1}
+	badBits := nextTestHeader(&genesis, genesis.Timestamp+1, genesis.Bits+1)
+	req := &util.HeaderChainRequest{
+		Checkpoint: []string{rawHeader(&genesis)},
+		Headers:    []string{rawHeader(orphan), rawHeader(badBits)},
+	}
+	jsonRequest, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jsonResult, err := BytomVerifyHeaders(jsonRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := &util.HeaderChainResult{}
+	if err := json.Unmarshal(jsonResult, result); err != nil {
+		t.Fatal(err)
+	}
+
+	genesisHash := genesis.Hash()
+	if result.BestHash != genesisHash.String() || result.BestHeight != 0 {
+		t.Errorf("best header: got %s at %d, want the genesis block", result.BestHash, result.BestHeight)
+	}
+	if result.WorkSum != difficulty.CalcWork(genesis.Bits).String() {
+		t.Errorf("work sum: got %s", result.WorkSum)
+	}
+	wantErrors := []error{errOrphanHeader, errBadBits}
+	if len(result.Headers) != len(wantErrors) {
+		t.Fatalf("got %d header status, want %d", len(result.Headers), len(wantErrors))
+	}
+	for i, status := range result.Headers {
+		if status.Valid || status.Error != wantErrors[i].Error() {
+			t.Errorf("header %d: got valid %t error %q, want error %q", i, status.Valid, status.Error, wantErrors[i])
+		}
+	}
+
+	for _, badRequest := range []string{`{"checkpoint": "00"}`, `{"checkpoint": []}`} {
+		_, err := BytomVerifyHeaders([]byte(badRequest))
+		if decodeErr, ok := err.(*util.DecodeError); !ok || decodeErr.Kind != util.ErrKindInvalidHeader {
+			t.Errorf("request %s: got error %v, want kind %s", badRequest, err, util.ErrKindInvalidHeader)
+		}
+	}
+}
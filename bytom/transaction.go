@@ -4,22 +4,79 @@ import (
 	"encoding/hex"
 	"encoding/json"
 
-	"github.com/bytom/bytom/blockchain/txbuilder"
 	"github.com/bytom/bytom/common"
 	"github.com/bytom/bytom/consensus"
 	"github.com/bytom/bytom/consensus/segwit"
+	"github.com/bytom/bytom/math/checked"
 	"github.com/bytom/bytom/protocol/bc"
 	"github.com/bytom/bytom/protocol/bc/types"
+	"github.com/bytom/bytom/protocol/vm"
 	"github.com/bytom/bytom/protocol/vm/vmutil"
 
 	"github.com/vapor-sdk/util"
 )
 
-// BytomDecodeRawTx decode raw transaction
+const chainName = "bytom"
+
+// BytomDecodeRawTx decode raw transaction, a JSON error envelope is returned
+// when the transaction can not be decoded
 func BytomDecodeRawTx(rawTransaction string) []byte {
-	var rawTx types.Tx
+	jsonTx, err := BytomDecodeRawTxWithError(rawTransaction)
+	if err != nil {
+		return util.MarshalErrorEnvelope(err)
+	}
+	return jsonTx
+}
+
+// BytomDecodeRawTxWithError decode raw transaction on mainnet, the returned
+// error is a *util.DecodeError
+func BytomDecodeRawTxWithError(rawTransaction string) ([]byte, error) {
+	return BytomDecodeRawTxWithOptions(rawTransaction, util.DecodeOptions{})
+}
+
+// BytomDecodeRawTxWithOptions decode raw transaction with the given options,
+// the returned error is a *util.DecodeError
+func BytomDecodeRawTxWithOptions(rawTransaction string, opts util.DecodeOptions) ([]byte, error) {
+	tx, err := BytomDecodeTx(rawTransaction, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonTx, err := util.MarshalTransaction(tx, opts)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindMarshal, chainName, err)
+	}
+	return jsonTx, nil
+}
+
+// BytomDecodeTx decode raw transaction into the annotated transaction, the
+// returned error is a *util.DecodeError
+func BytomDecodeTx(rawTransaction string, opts util.DecodeOptions) (*util.Transaction, error) {
+	rawTx, err := BytomParseRawTx(rawTransaction)
+	if err != nil {
+		return nil, err
+	}
+	return BytomAnnotateTx(rawTx, opts)
+}
+
+// BytomParseRawTx parse raw transaction into the underlying types.Tx, the
+// returned error is a *util.DecodeError
+func BytomParseRawTx(rawTransaction string) (*types.Tx, error) {
+	rawTx := &types.Tx{}
 	if err := rawTx.UnmarshalText([]byte(rawTransaction)); err != nil {
-		return nil
+		return nil, util.NewRawTxError(chainName, rawTransaction, err, func(hexTx []byte) error {
+			return new(types.TxData).UnmarshalText(hexTx)
+		})
+	}
+	return rawTx, nil
+}
+
+// BytomAnnotateTx build the annotated transaction of a parsed types.Tx, the
+// returned error is a *util.DecodeError
+func BytomAnnotateTx(rawTx *types.Tx, opts util.DecodeOptions) (*util.Transaction, error) {
+	netParams, err := getNetParams(opts.Network)
+	if err != nil {
+		return nil, err
 	}
 
 	tx := &util.Transaction{
@@ -29,33 +86,41 @@ func BytomDecodeRawTx(rawTransaction string) []byte {
 		TimeRange: int64(rawTx.TimeRange),
 		Inputs:    []util.AnnotatedInput{},
 		Outputs:   []util.AnnotatedOutput{},
-		Fee:       int64(txbuilder.CalculateTxFee(&rawTx)),
+	}
+
+	if txFee, ok := calculateTxFee(rawTx); ok {
+		tx.Fee = txFee
+	} else {
+		tx.FeeOverflow = true
 	}
 
 	for i := range rawTx.Inputs {
-		tx.Inputs = append(tx.Inputs, buildAnnotatedInput(&rawTx, uint32(i)))
+		in := buildAnnotatedInput(rawTx, uint32(i), netParams)
+		if opts.VerifySignatures {
+			verifyInputWitness(rawTx, uint32(i), &in)
+		}
+		tx.Inputs = append(tx.Inputs, in)
 	}
 	for i := range rawTx.Outputs {
-		tx.Outputs = append(tx.Outputs, buildAnnotatedOutput(&rawTx, i))
+		tx.Outputs = append(tx.Outputs, buildAnnotatedOutput(rawTx, i, netParams))
 	}
-
-	jsonTx, err := json.Marshal(tx)
-	if err != nil {
-		return nil
-	}
-	return jsonTx
+	return tx, nil
 }
 
 // buildAnnotatedInput build the annotated input.
-func buildAnnotatedInput(tx *types.Tx, i uint32) util.AnnotatedInput {
+func buildAnnotatedInput(tx *types.Tx, i uint32, netParams *consensus.Params) util.AnnotatedInput {
 	orig := tx.Inputs[i]
 	in := util.AnnotatedInput{}
 	if orig.InputType() != types.CoinbaseInputType {
 		assetID := orig.AssetID()
 		in.AssetID = assetID.String()
-		in.Amount = int64(orig.Amount())
+		in.Amount = orig.Amount()
 		signData := tx.SigHash(i)
 		in.SignData = signData.String()
+		if spendInput, ok := orig.TypedInput.(*types.SpendInput); ok {
+			in.SourceID = spendInput.SourceID.String()
+			in.SourcePosition = spendInput.SourcePosition
+		}
 	} else {
 		in.AssetID = consensus.BTMAssetID.String()
 	}
@@ -68,7 +133,9 @@ func buildAnnotatedInput(tx *types.Tx, i uint32) util.AnnotatedInput {
 		in.Type = "spend"
 		controlProgram := orig.ControlProgram()
 		in.ControlProgram = hex.EncodeToString(controlProgram)
-		in.Address = getAddressFromControlProgram(controlProgram)
+		in.Address = getAddressFromControlProgram(controlProgram, netParams)
+		in.ScriptAsm = disassembleProgram(controlProgram)
+		in.ScriptType = getScriptType(controlProgram)
 		in.SpentOutputID = e.SpentOutputId.String()
 		arguments := orig.Arguments()
 		for _, arg := range arguments {
@@ -77,14 +144,22 @@ func buildAnnotatedInput(tx *types.Tx, i uint32) util.AnnotatedInput {
 
 	case *bc.Issuance:
 		in.Type = "issue"
+		if issuanceInput, ok := orig.TypedInput.(*types.IssuanceInput); ok {
+			in.Nonce = hex.EncodeToString(issuanceInput.Nonce)
+			in.IssuanceVMVersion = issuanceInput.VMVersion
+		}
 		issuanceProgram := orig.IssuanceProgram()
 		in.IssuanceProgram = hex.EncodeToString(issuanceProgram)
+		in.IssuanceProgramAsm = disassembleProgram(issuanceProgram)
+		in.IssuanceProgramType = getScriptType(issuanceProgram)
 		arguments := orig.Arguments()
 		for _, arg := range arguments {
 			in.WitnessArguments = append(in.WitnessArguments, hex.EncodeToString(arg))
 		}
-		if assetDefinition := orig.AssetDefinition(); isValidJSON(assetDefinition) {
-			in.AssetDefinition = hex.EncodeToString(assetDefinition)
+		assetDefinition := orig.AssetDefinition()
+		in.AssetDefinition = hex.EncodeToString(assetDefinition)
+		if isValidJSON(assetDefinition) {
+			in.ParsedAssetDefinition = json.RawMessage(assetDefinition)
 		}
 
 	case *bc.Coinbase:
@@ -94,56 +169,116 @@ func buildAnnotatedInput(tx *types.Tx, i uint32) util.AnnotatedInput {
 	return in
 }
 
+// verifyInputWitness set the signature status of the input at i, inputs which
+// are not signed by a key are left out.
+func verifyInputWitness(tx *types.Tx, i uint32, in *util.AnnotatedInput) {
+	orig := tx.Inputs[i]
+	var program []byte
+	switch orig.InputType() {
+	case types.SpendInputType:
+		program = orig.ControlProgram()
+	case types.IssuanceInputType:
+		program = orig.IssuanceProgram()
+	default:
+		return
+	}
+
+	status, err := util.VerifyWitness(program, orig.Arguments(), tx.SigHash(i).Byte32())
+	in.SignatureStatus = status
+	if err != nil {
+		in.SignatureError = err.Error()
+	}
+}
+
 // buildAnnotatedOutput build the annotated output.
-func buildAnnotatedOutput(tx *types.Tx, idx int) util.AnnotatedOutput {
+func buildAnnotatedOutput(tx *types.Tx, idx int, netParams *consensus.Params) util.AnnotatedOutput {
 	orig := tx.Outputs[idx]
 	outid := tx.OutputID(idx)
 	out := util.AnnotatedOutput{
 		OutputID:       outid.String(),
 		Position:       idx,
 		AssetID:        orig.AssetId.String(),
-		Amount:         int64(orig.Amount),
+		Amount:         orig.Amount,
 		ControlProgram: hex.EncodeToString(orig.ControlProgram),
-		Address:        getAddressFromControlProgram(orig.ControlProgram),
+		ScriptAsm:      disassembleProgram(orig.ControlProgram),
+		ScriptType:     getScriptType(orig.ControlProgram),
+		Address:        getAddressFromControlProgram(orig.ControlProgram, netParams),
 	}
 
 	if vmutil.IsUnspendable(orig.ControlProgram) {
 		out.Type = "retire"
+		out.RetireComment = hex.EncodeToString(getRetireComment(orig.ControlProgram))
 	} else {
 		out.Type = "control"
 	}
 	return out
 }
 
+// calculateTxFee is txbuilder.CalculateTxFee with checked arithmetic, it
+// reports false instead of wrapping when the BTM totals overflow
+func calculateTxFee(tx *types.Tx) (uint64, bool) {
+	var fee uint64
+	var ok bool
+	for _, input := range tx.Inputs {
+		if input.InputType() == types.CoinbaseInputType {
+			return 0, true
+		}
+		if input.AssetID() == *consensus.BTMAssetID {
+			if fee, ok = checked.AddUint64(fee, input.Amount()); !ok {
+				return 0, false
+			}
+		}
+	}
+
+	for _, output := range tx.Outputs {
+		if *output.AssetId == *consensus.BTMAssetID {
+			if fee, ok = checked.SubUint64(fee, output.Amount); !ok {
+				return 0, false
+			}
+		}
+	}
+	return fee, true
+}
+
 func isValidJSON(b []byte) bool {
 	var v interface{}
 	err := json.Unmarshal(b, &v)
 	return err == nil
 }
 
-func getAddressFromControlProgram(prog []byte) string {
+// getRetireComment returns the comment that vmutil.RetireProgram embeds after
+// OP_FAIL, or nil if the program carries none
+func getRetireComment(prog []byte) []byte {
+	insts, err := vm.ParseProgram(prog)
+	if err != nil || len(insts) != 2 || insts[0].Op != vm.OP_FAIL || !insts[1].IsPushdata() {
+		return nil
+	}
+	return insts[1].Data
+}
+
+func getAddressFromControlProgram(prog []byte, netParams *consensus.Params) string {
 	if segwit.IsP2WPKHScript(prog) {
 		if pubHash, err := segwit.GetHashFromStandardProg(prog); err == nil {
-			return buildP2PKHAddress(pubHash)
+			return buildP2PKHAddress(pubHash, netParams)
 		}
 	} else if segwit.IsP2WSHScript(prog) {
 		if scriptHash, err := segwit.GetHashFromStandardProg(prog); err == nil {
-			return buildP2SHAddress(scriptHash)
+			return buildP2SHAddress(scriptHash, netParams)
 		}
 	}
 	return ""
 }
 
-func buildP2PKHAddress(pubHash []byte) string {
-	address, err := common.NewAddressWitnessPubKeyHash(pubHash, &consensus.MainNetParams)
+func buildP2PKHAddress(pubHash []byte, netParams *consensus.Params) string {
+	address, err := common.NewAddressWitnessPubKeyHash(pubHash, netParams)
 	if err != nil {
 		return ""
 	}
 	return address.EncodeAddress()
 }
 
-func buildP2SHAddress(scriptHash []byte) string {
-	address, err := common.NewAddressWitnessScriptHash(scriptHash, &consensus.MainNetParams)
+func buildP2SHAddress(scriptHash []byte, netParams *consensus.Params) string {
+	address, err := common.NewAddressWitnessScriptHash(scriptHash, netParams)
 	if err != nil {
 		return ""
 	}
@@ -3,8 +3,12 @@ package transaction
 import (
 	"encoding/json"
 	"errors"
+	"math"
 	"testing"
 
+	"github.com/bytom/bytom/consensus"
+	"github.com/bytom/bytom/protocol/bc"
+	"github.com/bytom/bytom/protocol/bc/types"
 	"github.com/bytom/bytom/testutil"
 
 	"github.com/vapor-sdk/util"
@@ -30,8 +34,11 @@ func TestBytomDecodeRawTx(t *testing.T) {
 						AssetID:        "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
 						Amount:         41250000000,
 						ControlProgram: "001456ac170c7965eeac1cc34928c9f464e3f88c17d8",
+						ScriptAsm:      "FALSE 0x56ac170c7965eeac1cc34928c9f464e3f88c17d8",
+						ScriptType:     "p2wpkh",
 						Address:        "bm1q26kpwrrevhh2c8xrfy5vnaryu0ugc97c3j896t",
 						SpentOutputID:  "01bb3309666618a1507cb5be845b17dee5eb8028ee7e71b17d74b4dc97085bc8",
+						SourceID:       "c8215913a270d3d953ef431626b19a89adf38e2486bb235da732f0afed515299",
 						WitnessArguments: []string{
 							"b1e99a3590d7db80126b273088937a87ba1e8d2f91021a2fd2c36579f7713926e8c7b46c047a43933b008ff16ecc2eb8ee888b4ca1fe3fdf082824e0b3899b02",
 							"2fb851c6ed665fcd9ebc259da1461a1e284ac3b27f5e86c84164aa5186482226",
@@ -47,6 +54,8 @@ func TestBytomDecodeRawTx(t *testing.T) {
 						AssetID:        "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
 						Amount:         41030000000,
 						ControlProgram: "0014c3d320e1dc4fe787e9f13c1464e3ea5aae96a58f",
+						ScriptAsm:      "FALSE 0xc3d320e1dc4fe787e9f13c1464e3ea5aae96a58f",
+						ScriptType:     "p2wpkh",
 						Address:        "bm1qc0fjpcwuflnc06038s2xfcl2t2hfdfv07hgf77",
 					},
 					util.AnnotatedOutput{
@@ -56,6 +65,8 @@ func TestBytomDecodeRawTx(t *testing.T) {
 						AssetID:        "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
 						Amount:         200000000,
 						ControlProgram: "0014bb93cdb4eca74b068321eeb84ac5d33686281b65",
+						ScriptAsm:      "FALSE 0xbb93cdb4eca74b068321eeb84ac5d33686281b65",
+						ScriptType:     "p2wpkh",
 						Address:        "bm1qhwfumd8v5a9sdqepa6uy43wnx6rzsxm9cp6j43",
 					},
 				},
@@ -80,3 +91,184 @@ func TestBytomDecodeRawTx(t *testing.T) {
 		}
 	}
 }
+
+func TestBytomDecodeRawTxWithError(t *testing.T) {
+	rawTransaction := `070100010161015fc8215913a270d3d953ef431626b19a89adf38e2486bb235da732f0afed515299ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff8099c4d59901000116001456ac170c7965eeac1cc34928c9f464e3f88c17d8630240b1e99a3590d7db80126b273088937a87ba1e8d2f91021a2fd2c36579f7713926e8c7b46c047a43933b008ff16ecc2eb8ee888b4ca1fe3fdf082824e0b3899b02202fb851c6ed665fcd9ebc259da1461a1e284ac3b27f5e86c84164aa518648222602013effffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff80bbd0ec980101160014c3d320e1dc4fe787e9f13c1464e3ea5aae96a58f00013cffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff8084af5f01160014bb93cdb4eca74b068321eeb84ac5d33686281b6500`
+	cases := []struct {
+		desc           string
+		rawTransaction string
+		wantKind       util.ErrorKind
+		wantOffset     int
+	}{
+		{
+			desc:           "invalid hex character",
+			rawTransaction: "0701zz",
+			wantKind:       util.ErrKindInvalidHex,
+			wantOffset:     2,
+		},
+		{
+			desc:           "odd length hex",
+			rawTransaction: rawTransaction[:len(rawTransaction)-1],
+			wantKind:       util.ErrKindInvalidHex,
+			wantOffset:     331,
+		},
+		{
+			desc:           "unsupported serialization flags",
+			rawTransaction: "08" + rawTransaction[2:],
+			wantKind:       util.ErrKindMalformedTx,
+			wantOffset:     0,
+		},
+		{
+			desc:           "truncated input",
+			rawTransaction: rawTransaction[:60],
+			wantKind:       util.ErrKindMalformedTx,
+			wantOffset:     4,
+		},
+		{
+			desc:           "truncated output",
+			rawTransaction: rawTransaction[:len(rawTransaction)-10],
+			wantKind:       util.ErrKindMalformedTx,
+			wantOffset:     269,
+		},
+		{
+			desc:           "trailing garbage",
+			rawTransaction: rawTransaction + "00",
+			wantKind:       util.ErrKindMalformedTx,
+			wantOffset:     332,
+		},
+	}
+
+	for i, c := range cases {
+		_, err := BytomDecodeRawTxWithError(c.rawTransaction)
+		decodeErr, ok := err.(*util.DecodeError)
+		if !ok {
+			t.Fatalf("case #%d (%s), got error %v, want *util.DecodeError", i, c.desc, err)
+		}
+
+		if decodeErr.Kind != c.wantKind || decodeErr.Offset != c.wantOffset || decodeErr.Chain != "bytom" {
+			t.Errorf("case #%d (%s), got kind=%s offset=%d chain=%s, want kind=%s offset=%d chain=bytom", i, c.desc, decodeErr.Kind, decodeErr.Offset, decodeErr.Chain, c.wantKind, c.wantOffset)
+		}
+	}
+}
+
+func TestBytomDecodeRawTxNetwork(t *testing.T) {
+	rawTransaction := `070100010161015fc8215913a270d3d953ef431626b19a89adf38e2486bb235da732f0afed515299ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff8099c4d59901000116001456ac170c7965eeac1cc34928c9f464e3f88c17d8630240b1e99a3590d7db80126b273088937a87ba1e8d2f91021a2fd2c36579f7713926e8c7b46c047a43933b008ff16ecc2eb8ee888b4ca1fe3fdf082824e0b3899b02202fb851c6ed665fcd9ebc259da1461a1e284ac3b27f5e86c84164aa518648222602013effffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff80bbd0ec980101160014c3d320e1dc4fe787e9f13c1464e3ea5aae96a58f00013cffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff8084af5f01160014bb93cdb4eca74b068321eeb84ac5d33686281b6500`
+	cases := []struct {
+		network     string
+		wantAddress string
+	}{
+		{
+			network:     "mainnet",
+			wantAddress: "bm1q26kpwrrevhh2c8xrfy5vnaryu0ugc97c3j896t",
+		},
+		{
+			network:     "testnet",
+			wantAddress: "tm1q26kpwrrevhh2c8xrfy5vnaryu0ugc97c4yxp66",
+		},
+		{
+			network:     "wisdom",
+			wantAddress: "tm1q26kpwrrevhh2c8xrfy5vnaryu0ugc97c4yxp66",
+		},
+		{
+			network:     "solonet",
+			wantAddress: "sm1q26kpwrrevhh2c8xrfy5vnaryu0ugc97csrdy69",
+		},
+	}
+
+	for i, c := range cases {
+		jsonTx, err := BytomDecodeRawTxWithOptions(rawTransaction, util.DecodeOptions{Network: c.network})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		gotTx := &util.Transaction{}
+		if err := json.Unmarshal(jsonTx, gotTx); err != nil {
+			t.Fatal(err)
+		}
+
+		if gotTx.Inputs[0].Address != c.wantAddress {
+			t.Errorf("case #%d, got address %s, want %s", i, gotTx.Inputs[0].Address, c.wantAddress)
+		}
+	}
+}
+
+func TestBytomDecodeTx(t *testing.T) {
+	rawTransaction := `070100010161015fc8215913a270d3d953ef431626b19a89adf38e2486bb235da732f0afed515299ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff8099c4d59901000116001456ac170c7965eeac1cc34928c9f464e3f88c17d8630240b1e99a3590d7db80126b273088937a87ba1e8d2f91021a2fd2c36579f7713926e8c7b46c047a43933b008ff16ecc2eb8ee888b4ca1fe3fdf082824e0b3899b02202fb851c6ed665fcd9ebc259da1461a1e284ac3b27f5e86c84164aa518648222602013effffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff80bbd0ec980101160014c3d320e1dc4fe787e9f13c1464e3ea5aae96a58f00013cffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff8084af5f01160014bb93cdb4eca74b068321eeb84ac5d33686281b6500`
+	gotTx, err := BytomDecodeTx(rawTransaction, util.DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rawTx, err := BytomParseRawTx(rawTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gotTx.TxID != rawTx.ID.String() {
+		t.Errorf("got tx id %s, want %s", gotTx.TxID, rawTx.ID.String())
+	}
+
+	jsonTx, err := BytomDecodeRawTxWithError(rawTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantTx := &util.Transaction{}
+	if err := json.Unmarshal(jsonTx, wantTx); err != nil {
+		t.Fatal(err)
+	}
+
+	if !testutil.DeepEqual(gotTx, wantTx) {
+		t.Errorf("annotated transaction got=%#v, want=%#v", gotTx, wantTx)
+	}
+}
+
+func TestBytomDecodeTxAmounts(t *testing.T) {
+	controlProgram := testutil.MustDecodeHexString("0014c3d320e1dc4fe787e9f13c1464e3ea5aae96a58f")
+	rawTx := types.NewTx(types.TxData{
+		Version: 1,
+		Inputs: []*types.TxInput{
+			types.NewSpendInput(nil, bc.NewHash([32]byte{1}), *consensus.BTMAssetID, math.MaxInt64, 0, controlProgram),
+			types.NewSpendInput(nil, bc.NewHash([32]byte{2}), *consensus.BTMAssetID, math.MaxInt64, 0, controlProgram),
+			types.NewSpendInput(nil, bc.NewHash([32]byte{3}), *consensus.BTMAssetID, 2, 0, controlProgram),
+		},
+		Outputs: []*types.TxOutput{
+			types.NewTxOutput(*consensus.BTMAssetID, math.MaxInt64, controlProgram),
+		},
+	})
+	rawTransaction, err := rawTx.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gotTx, err := BytomDecodeTx(string(rawTransaction), util.DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gotTx.Inputs[0].Amount != math.MaxInt64 || gotTx.Outputs[0].Amount != math.MaxInt64 {
+		t.Errorf("got amounts %d %d, want %d", gotTx.Inputs[0].Amount, gotTx.Outputs[0].Amount, uint64(math.MaxInt64))
+	}
+	if !gotTx.FeeOverflow || gotTx.Fee != 0 {
+		t.Errorf("got fee=%d fee_overflow=%t, want fee=0 fee_overflow=true", gotTx.Fee, gotTx.FeeOverflow)
+	}
+
+	jsonTx, err := BytomDecodeRawTxWithOptions(string(rawTransaction), util.DecodeOptions{StringAmounts: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stringTx struct {
+		Inputs []struct {
+			Amount string `json:"amount"`
+		} `json:"inputs"`
+		Fee string `json:"fee"`
+	}
+	if err := json.Unmarshal(jsonTx, &stringTx); err != nil {
+		t.Fatal(err)
+	}
+
+	if stringTx.Inputs[0].Amount != "9223372036854775807" || stringTx.Fee != "0" {
+		t.Errorf("got string amount=%s fee=%s, want amount=9223372036854775807 fee=0", stringTx.Inputs[0].Amount, stringTx.Fee)
+	}
+}
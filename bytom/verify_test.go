@@ -0,0 +1,143 @@
+package transaction
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/bytom/bytom/consensus"
+	"github.com/bytom/bytom/crypto/ed25519/chainkd"
+
+	"github.com/vapor-sdk/util"
+)
+
+// setTestArguments returns rawTransaction with the witness arguments of the
+// input at position replaced
+func setTestArguments(t *testing.T, rawTransaction string, position uint32, args [][]byte) string {
+	tx, err := BytomParseRawTx(rawTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx.SetInputArguments(position, args)
+	data, err := tx.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// testArguments returns the witness arguments of the input at position
+func testArguments(t *testing.T, rawTransaction string, position int) [][]byte {
+	tx, err := BytomParseRawTx(rawTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx.Inputs[position].Arguments()
+}
+
+func TestBytomVerifySignatures(t *testing.T) {
+	p2wpkhTpl := testSignTemplate(t, "alice")
+	if _, err := BytomSignTemplate(p2wpkhTpl, util.XPrvSignFunc(chainkd.RootXPrv([]byte("alice")))); err != nil {
+		t.Fatal(err)
+	}
+	p2wpkhArgs := testArguments(t, p2wpkhTpl.RawTransaction, 0)
+	_, bobProgram := testP2WPKHKey(t, "bob")
+
+	multiSig, err := BytomMultiSig(testMultiSigKeys(t, "alice", "bob", "carol"), 2, "mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	btm := consensus.BTMAssetID.String()
+	multiSigTpl, err := BytomBuild([]Action{
+		&SpendUTXOAction{UTXO: util.UTXO{
+			SourceID:       "0100000000000000000000000000000000000000000000000000000000000000",
+			AssetID:        btm,
+			Amount:         100000000,
+			ControlProgram: multiSig.ControlProgram,
+			Keys:           multiSig.Keys,
+			Quorum:         multiSig.Quorum,
+		}},
+		&SpendUTXOAction{UTXO: util.UTXO{
+			SourceID:       "0200000000000000000000000000000000000000000000000000000000000000",
+			AssetID:        btm,
+			Amount:         100000000,
+			ControlProgram: "51",
+		}},
+		&ControlProgramAction{AssetAmount: util.AssetAmount{AssetID: btm, Amount: 190000000}, ControlProgram: hex.EncodeToString(bobProgram)},
+	}, 0, "mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := BytomSignTemplate(multiSigTpl, util.XPrvSignFunc(chainkd.RootXPrv([]byte("alice")), chainkd.RootXPrv([]byte("carol")))); err != nil {
+		t.Fatal(err)
+	}
+	multiSigArgs := testArguments(t, multiSigTpl.RawTransaction, 0)
+
+	cases := []struct {
+		desc           string
+		rawTransaction string
+		want           []string
+	}{
+		{
+			desc:           "p2wpkh",
+			rawTransaction: p2wpkhTpl.RawTransaction,
+			want:           []string{util.SignatureValid, util.SignatureMissing},
+		},
+		{
+			desc:           "p2wpkh with the witness of another input",
+			rawTransaction: setTestArguments(t, p2wpkhTpl.RawTransaction, 1, p2wpkhArgs),
+			want:           []string{util.SignatureValid, util.SignatureInvalid},
+		},
+		{
+			desc:           "p2wpkh with the public key of another key",
+			rawTransaction: setTestArguments(t, p2wpkhTpl.RawTransaction, 0, [][]byte{p2wpkhArgs[0], chainkd.RootXPrv([]byte("bob")).XPub().PublicKey()}),
+			want:           []string{util.SignatureInvalid, util.SignatureMissing},
+		},
+		{
+			desc:           "p2wpkh with a corrupted signature",
+			rawTransaction: setTestArguments(t, p2wpkhTpl.RawTransaction, 0, [][]byte{append([]byte{1}, p2wpkhArgs[0][1:]...), p2wpkhArgs[1]}),
+			want:           []string{util.SignatureInvalid, util.SignatureMissing},
+		},
+		{
+			desc:           "p2wsh multisig",
+			rawTransaction: multiSigTpl.RawTransaction,
+			want:           []string{util.SignatureValid, util.SignatureUnsupported},
+		},
+		{
+			desc:           "p2wsh multisig with signatures out of key order",
+			rawTransaction: setTestArguments(t, multiSigTpl.RawTransaction, 0, [][]byte{multiSigArgs[1], multiSigArgs[0], multiSigArgs[2]}),
+			want:           []string{util.SignatureInvalid, util.SignatureUnsupported},
+		},
+		{
+			desc:           "p2wsh multisig below quorum",
+			rawTransaction: setTestArguments(t, multiSigTpl.RawTransaction, 0, [][]byte{multiSigArgs[0], multiSigArgs[2]}),
+			want:           []string{util.SignatureMissing, util.SignatureUnsupported},
+		},
+		{
+			desc:           "p2wsh multisig with another redeem program",
+			rawTransaction: setTestArguments(t, multiSigTpl.RawTransaction, 0, [][]byte{multiSigArgs[0], multiSigArgs[1], bobProgram}),
+			want:           []string{util.SignatureInvalid, util.SignatureUnsupported},
+		},
+	}
+
+	for _, c := range cases {
+		tx, err := BytomDecodeTx(c.rawTransaction, util.DecodeOptions{VerifySignatures: true})
+		if err != nil {
+			t.Fatalf("%s: %v", c.desc, err)
+		}
+
+		for i, in := range tx.Inputs {
+			if in.SignatureStatus != c.want[i] || (in.SignatureStatus == util.SignatureValid) != (in.SignatureError == "") {
+				t.Errorf("%s: input #%d, got status %s (%s), want %s", c.desc, i, in.SignatureStatus, in.SignatureError, c.want[i])
+			}
+		}
+	}
+
+	tx, err := BytomDecodeTx(p2wpkhTpl.RawTransaction, util.DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Inputs[0].SignatureStatus != "" {
+		t.Errorf("got signature status %s without verification", tx.Inputs[0].SignatureStatus)
+	}
+}
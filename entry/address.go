@@ -0,0 +1,93 @@
+package entry
+
+import (
+	"encoding/json"
+
+	bytomsdk "github.com/vapor-sdk/bytom"
+	"github.com/vapor-sdk/util"
+	vaporsdk "github.com/vapor-sdk/vapor"
+)
+
+// DecodeAddress decode a bytom or vapor address of the network and return
+// the util.Address as JSON, the returned error is a *util.DecodeError
+func DecodeAddress(chainName, address, network string) ([]byte, error) {
+	decoded, err := decodeAddress(chainName, address, network)
+	if err != nil {
+		return nil, err
+	}
+	return marshalAddress(chainName, decoded)
+}
+
+// ValidateAddress returns nil if address is a bytom or vapor address of the
+// network, or a *util.DecodeError telling why it is not
+func ValidateAddress(chainName, address, network string) error {
+	switch chainName {
+	case "bytom":
+		return bytomsdk.BytomValidateAddress(address, network)
+	case "vapor":
+		return vaporsdk.VaporValidateAddress(address, network)
+	default:
+		return unsupportedChainError(chainName)
+	}
+}
+
+// ProgramAddress returns the util.Address of a hex control program on a bytom
+// or vapor network as JSON, the returned error is a *util.DecodeError
+func ProgramAddress(chainName, controlProgram, network string) ([]byte, error) {
+	var decoded *util.Address
+	var err error
+	switch chainName {
+	case "bytom":
+		decoded, err = bytomsdk.BytomProgramAddress(controlProgram, network)
+	case "vapor":
+		decoded, err = vaporsdk.VaporProgramAddress(controlProgram, network)
+	default:
+		return nil, unsupportedChainError(chainName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return marshalAddress(chainName, decoded)
+}
+
+// ConvertAddress re-encode the witness program of an address of fromChain as
+// an address of toChain. The network is the vapor network, bytom mainnet
+// pairs with vapor mainnet like the cross-chain outputs of vapor. The
+// returned error is a *util.DecodeError
+func ConvertAddress(fromChain, toChain, address, network string) (string, error) {
+	switch {
+	case fromChain == "bytom" && toChain == "vapor":
+		return vaporsdk.BytomToVaporAddress(address, network)
+	case fromChain == "vapor" && toChain == "bytom":
+		return vaporsdk.VaporToBytomAddress(address, network)
+	case fromChain == toChain:
+		decoded, err := decodeAddress(fromChain, address, network)
+		if err != nil {
+			return "", err
+		}
+		return decoded.Address, nil
+	case fromChain != "bytom" && fromChain != "vapor":
+		return "", unsupportedChainError(fromChain)
+	default:
+		return "", unsupportedChainError(toChain)
+	}
+}
+
+func decodeAddress(chainName, address, network string) (*util.Address, error) {
+	switch chainName {
+	case "bytom":
+		return bytomsdk.BytomDecodeAddress(address, network)
+	case "vapor":
+		return vaporsdk.VaporDecodeAddress(address, network)
+	default:
+		return nil, unsupportedChainError(chainName)
+	}
+}
+
+func marshalAddress(chainName string, address *util.Address) ([]byte, error) {
+	jsonAddress, err := json.Marshal(address)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindMarshal, chainName, err)
+	}
+	return jsonAddress, nil
+}
@@ -0,0 +1,80 @@
+package entry
+
+import (
+	bytomsdk "github.com/vapor-sdk/bytom"
+	"github.com/vapor-sdk/util"
+	vaporsdk "github.com/vapor-sdk/vapor"
+)
+
+// DecodeRawBlock decode full raw block for bytom and vapor, a JSON error
+// envelope is returned when the block can not be decoded
+func DecodeRawBlock(chainName, rawBlock string) []byte {
+	jsonBlock, err := DecodeRawBlockWithOptions(chainName, rawBlock, util.BlockModeFull, util.DecodeOptions{})
+	if err != nil {
+		return util.MarshalErrorEnvelope(err)
+	}
+	return jsonBlock
+}
+
+// DecodeRawBlockWithOptions decode raw block for bytom and vapor with the given
+// mode and options, the returned error is a *util.DecodeError
+func DecodeRawBlockWithOptions(chainName, rawBlock string, mode util.BlockMode, opts util.DecodeOptions) ([]byte, error) {
+	switch chainName {
+	case "bytom":
+		return bytomsdk.BytomDecodeRawBlockWithOptions(rawBlock, mode, opts)
+	case "vapor":
+		return vaporsdk.VaporDecodeRawBlockWithOptions(rawBlock, mode, opts)
+	default:
+		return nil, unsupportedChainError(chainName)
+	}
+}
+
+// DecodeBlock decode raw block for bytom and vapor into the annotated block,
+// the returned error is a *util.DecodeError
+func DecodeBlock(chainName, rawBlock string, mode util.BlockMode, opts util.DecodeOptions) (*util.Block, error) {
+	switch chainName {
+	case "bytom":
+		return bytomsdk.BytomDecodeBlock(rawBlock, mode, opts)
+	case "vapor":
+		return vaporsdk.VaporDecodeBlock(rawBlock, mode, opts)
+	default:
+		return nil, unsupportedChainError(chainName)
+	}
+}
+
+// VerifyBlockPoW verify the proof of work of a raw block or block header of
+// bytom with the hex seed of its epoch, vapor blocks have no proof of work.
+// The returned error is a *util.DecodeError
+func VerifyBlockPoW(chainName, rawBlock, seed string) (bool, error) {
+	switch chainName {
+	case "bytom":
+		return bytomsdk.BytomVerifyRawBlockPoW(rawBlock, seed)
+	default:
+		return false, unsupportedChainError(chainName)
+	}
+}
+
+// VerifyHeaders follow the bytom header chain of the JSON form of a
+// util.HeaderChainRequest from its trusted checkpoint and return the
+// util.HeaderChainResult as JSON, the returned error is a *util.DecodeError
+func VerifyHeaders(chainName string, jsonRequest []byte) ([]byte, error) {
+	switch chainName {
+	case "bytom":
+		return bytomsdk.BytomVerifyHeaders(jsonRequest)
+	default:
+		return nil, unsupportedChainError(chainName)
+	}
+}
+
+// VerifyBlockWitness verify the consensus node signatures of a vapor block
+// in the JSON form of a util.BlockWitnessRequest and return the
+// util.BlockWitnessResult as JSON, bytom blocks have no witness. The returned
+// error is a *util.DecodeError
+func VerifyBlockWitness(chainName string, jsonRequest []byte) ([]byte, error) {
+	switch chainName {
+	case "vapor":
+		return vaporsdk.VaporVerifyBlockWitness(jsonRequest)
+	default:
+		return nil, unsupportedChainError(chainName)
+	}
+}
@@ -0,0 +1,19 @@
+package entry
+
+import (
+	bytomsdk "github.com/vapor-sdk/bytom"
+	vaporsdk "github.com/vapor-sdk/vapor"
+)
+
+// BuildTx build an unsigned transaction template for bytom and vapor from the
+// JSON form of a util.BuildRequest, the returned error is a *util.DecodeError
+func BuildTx(chainName string, jsonRequest []byte) ([]byte, error) {
+	switch chainName {
+	case "bytom":
+		return bytomsdk.BytomBuildTx(jsonRequest)
+	case "vapor":
+		return vaporsdk.VaporBuildTx(jsonRequest)
+	default:
+		return nil, unsupportedChainError(chainName)
+	}
+}
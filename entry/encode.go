@@ -0,0 +1,19 @@
+package entry
+
+import (
+	bytomsdk "github.com/vapor-sdk/bytom"
+	vaporsdk "github.com/vapor-sdk/vapor"
+)
+
+// EncodeRawTx encode the JSON form of an annotated transaction for bytom and
+// vapor into raw transaction hex, the returned error is a *util.DecodeError
+func EncodeRawTx(chainName string, jsonTx []byte) (string, error) {
+	switch chainName {
+	case "bytom":
+		return bytomsdk.BytomEncodeRawTx(jsonTx)
+	case "vapor":
+		return vaporsdk.VaporEncodeRawTx(jsonTx)
+	default:
+		return "", unsupportedChainError(chainName)
+	}
+}
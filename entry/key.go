@@ -0,0 +1,138 @@
+package entry
+
+import (
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/bytom/bytom/crypto/ed25519/chainkd"
+
+	bytomsdk "github.com/vapor-sdk/bytom"
+	"github.com/vapor-sdk/util"
+	vaporsdk "github.com/vapor-sdk/vapor"
+)
+
+// NewKey returns a util.Key as JSON, the root key of the hex seed or a random
+// one when seed is empty. Keys are the same on bytom and vapor so the returned
+// *util.DecodeError has no chain.
+func NewKey(seed string) ([]byte, error) {
+	var key *util.Key
+	var err error
+	if seed == "" {
+		key, err = util.GenerateKey(nil)
+	} else {
+		var seedBytes []byte
+		if seedBytes, err = hex.DecodeString(seed); err != nil {
+			return nil, util.NewDecodeError(util.ErrKindInvalidHex, "", err)
+		}
+		key, err = util.SeedKey(seedBytes)
+	}
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindInvalidKey, "", err)
+	}
+
+	jsonKey, err := json.Marshal(key)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindMarshal, "", err)
+	}
+	return jsonKey, nil
+}
+
+// AccountPath returns the derivation path of an address of a bytom or vapor
+// account
+func AccountPath(account uint32, change bool, index uint32) string {
+	return util.AccountPath(account, change, index)
+}
+
+// EncryptKey encrypts a hex xprv with password into a key file of the Bytom
+// node named alias, with the standard scrypt parameters, and returns the
+// util.EncryptedKey as JSON. The returned error is a *util.DecodeError with no
+// chain.
+func EncryptKey(xprv, alias, password string) ([]byte, error) {
+	var key chainkd.XPrv
+	if err := key.UnmarshalText([]byte(xprv)); err != nil {
+		return nil, util.NewDecodeError(util.ErrKindInvalidKey, "", err)
+	}
+
+	encrypted, err := util.EncryptKey(key, alias, password, util.StandardScryptN, util.StandardScryptP)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindInvalidKey, "", err)
+	}
+
+	jsonKey, err := json.Marshal(encrypted)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindMarshal, "", err)
+	}
+	return jsonKey, nil
+}
+
+// DecryptKey decrypts the JSON form of a util.EncryptedKey, a key file of the
+// Bytom node, with password and returns the util.Key as JSON. The returned
+// error is a *util.DecodeError with no chain.
+func DecryptKey(keyJSON []byte, password string) ([]byte, error) {
+	encrypted := &util.EncryptedKey{}
+	if err := json.Unmarshal(keyJSON, encrypted); err != nil {
+		return nil, util.NewDecodeError(util.ErrKindInvalidKey, "", err)
+	}
+
+	xprv, err := util.DecryptKey(encrypted, password)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindInvalidKey, "", err)
+	}
+
+	jsonKey, err := json.Marshal(&util.Key{XPrv: xprv.String(), XPub: xprv.XPub().String()})
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindMarshal, "", err)
+	}
+	return jsonKey, nil
+}
+
+// DeriveKeys derive the keys, P2WPKH programs and addresses of bytom and vapor
+// from the JSON form of a util.DeriveRequest, the returned error is a
+// *util.DecodeError
+func DeriveKeys(chainName string, jsonRequest []byte) ([]byte, error) {
+	switch chainName {
+	case "bytom":
+		return bytomsdk.BytomDeriveKeys(jsonRequest)
+	case "vapor":
+		return vaporsdk.VaporDeriveKeys(jsonRequest)
+	default:
+		return nil, unsupportedChainError(chainName)
+	}
+}
+
+// NewMnemonic returns a random BIP39 mnemonic of entropyBits, 128 when it is
+// 0, in a language of the Bytom wallet such as 'en' or 'zh_CN'. The returned
+// error is a *util.DecodeError with no chain.
+func NewMnemonic(entropyBits int, language string) (string, error) {
+	mnemonic, err := util.GenerateMnemonic(entropyBits, language)
+	if err != nil {
+		return "", util.NewDecodeError(util.ErrKindInvalidMnemonic, "", err)
+	}
+	return mnemonic, nil
+}
+
+// ValidateMnemonic returns nil if mnemonic is a BIP39 mnemonic of language, or
+// of any supported language when it is empty, or a *util.DecodeError telling
+// why it is not
+func ValidateMnemonic(mnemonic, language string) error {
+	if err := util.ValidateMnemonic(mnemonic, language); err != nil {
+		return util.NewDecodeError(util.ErrKindInvalidMnemonic, "", err)
+	}
+	return nil
+}
+
+// MnemonicKey returns the util.Key restored from a BIP39 mnemonic and
+// passphrase as JSON, the same key as the Bytom wallet. The returned error is
+// a *util.DecodeError with no chain.
+func MnemonicKey(mnemonic, passphrase, language string) ([]byte, error) {
+	key, err := util.MnemonicKey(mnemonic, passphrase, language)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindInvalidMnemonic, "", err)
+	}
+
+	jsonKey, err := json.Marshal(key)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindMarshal, "", err)
+	}
+	return jsonKey, nil
+}
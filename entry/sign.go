@@ -0,0 +1,62 @@
+package entry
+
+import (
+	bytomsdk "github.com/vapor-sdk/bytom"
+	vaporsdk "github.com/vapor-sdk/vapor"
+)
+
+// SignTx sign a transaction template or raw transaction for bytom and vapor
+// from the JSON form of a util.SignRequest, the returned error is a
+// *util.DecodeError
+func SignTx(chainName string, jsonRequest []byte) ([]byte, error) {
+	switch chainName {
+	case "bytom":
+		return bytomsdk.BytomSignTx(jsonRequest)
+	case "vapor":
+		return vaporsdk.VaporSignTx(jsonRequest)
+	default:
+		return nil, unsupportedChainError(chainName)
+	}
+}
+
+// CreateMultiSig create an m-of-n multisig program and address for bytom and
+// vapor from the JSON form of a util.MultiSigRequest, the returned error is a
+// *util.DecodeError
+func CreateMultiSig(chainName string, jsonRequest []byte) ([]byte, error) {
+	switch chainName {
+	case "bytom":
+		return bytomsdk.BytomCreateMultiSig(jsonRequest)
+	case "vapor":
+		return vaporsdk.VaporCreateMultiSig(jsonRequest)
+	default:
+		return nil, unsupportedChainError(chainName)
+	}
+}
+
+// CombineTx combine partially signed copies of a transaction template for
+// bytom and vapor from the JSON form of a util.CombineRequest, the returned
+// error is a *util.DecodeError
+func CombineTx(chainName string, jsonRequest []byte) ([]byte, error) {
+	switch chainName {
+	case "bytom":
+		return bytomsdk.BytomCombineTx(jsonRequest)
+	case "vapor":
+		return vaporsdk.VaporCombineTx(jsonRequest)
+	default:
+		return nil, unsupportedChainError(chainName)
+	}
+}
+
+// AssembleScript assemble a program text into a program and its P2WSH
+// address for bytom and vapor from the JSON form of a util.AssembleRequest,
+// the returned error is a *util.DecodeError
+func AssembleScript(chainName string, jsonRequest []byte) ([]byte, error) {
+	switch chainName {
+	case "bytom":
+		return bytomsdk.BytomAssembleScript(jsonRequest)
+	case "vapor":
+		return vaporsdk.VaporAssembleScript(jsonRequest)
+	default:
+		return nil, unsupportedChainError(chainName)
+	}
+}
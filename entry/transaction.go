@@ -1,18 +1,66 @@
 package entry
 
 import (
+	"fmt"
+
 	bytomsdk "github.com/vapor-sdk/bytom"
+	"github.com/vapor-sdk/util"
 	vaporsdk "github.com/vapor-sdk/vapor"
 )
 
-// DecodeRawTx decode raw transaction for bytom and vapor
+// DecodeRawTx decode raw transaction for bytom and vapor, a JSON error
+// envelope is returned when the transaction can not be decoded
 func DecodeRawTx(chainName, rawTransaction string) []byte {
+	jsonTx, err := DecodeRawTxWithError(chainName, rawTransaction)
+	if err != nil {
+		return util.MarshalErrorEnvelope(err)
+	}
+	return jsonTx
+}
+
+// DecodeRawTxOnNetwork decode raw transaction for bytom and vapor with the
+// addresses rendered for the given network, a JSON error envelope is returned
+// when the transaction can not be decoded
+func DecodeRawTxOnNetwork(chainName, network, rawTransaction string) []byte {
+	jsonTx, err := DecodeRawTxWithOptions(chainName, rawTransaction, util.DecodeOptions{Network: network})
+	if err != nil {
+		return util.MarshalErrorEnvelope(err)
+	}
+	return jsonTx
+}
+
+// DecodeRawTxWithError decode raw transaction for bytom and vapor on mainnet,
+// the returned error is a *util.DecodeError
+func DecodeRawTxWithError(chainName, rawTransaction string) ([]byte, error) {
+	return DecodeRawTxWithOptions(chainName, rawTransaction, util.DecodeOptions{})
+}
+
+// DecodeRawTxWithOptions decode raw transaction for bytom and vapor with the
+// given options, the returned error is a *util.DecodeError
+func DecodeRawTxWithOptions(chainName, rawTransaction string, opts util.DecodeOptions) ([]byte, error) {
 	switch chainName {
 	case "bytom":
-		return bytomsdk.BytomDecodeRawTx(rawTransaction)
+		return bytomsdk.BytomDecodeRawTxWithOptions(rawTransaction, opts)
 	case "vapor":
-		return vaporsdk.VaporDecodeRawTx(rawTransaction)
+		return vaporsdk.VaporDecodeRawTxWithOptions(rawTransaction, opts)
 	default:
-		return nil
+		return nil, unsupportedChainError(chainName)
 	}
 }
+
+// DecodeTx decode raw transaction for bytom and vapor into the annotated
+// transaction, the returned error is a *util.DecodeError
+func DecodeTx(chainName, rawTransaction string, opts util.DecodeOptions) (*util.Transaction, error) {
+	switch chainName {
+	case "bytom":
+		return bytomsdk.BytomDecodeTx(rawTransaction, opts)
+	case "vapor":
+		return vaporsdk.VaporDecodeTx(rawTransaction, opts)
+	default:
+		return nil, unsupportedChainError(chainName)
+	}
+}
+
+func unsupportedChainError(chainName string) error {
+	return util.NewDecodeError(util.ErrKindUnsupportedChain, chainName, fmt.Errorf("unsupported chain %q", chainName))
+}
@@ -0,0 +1,21 @@
+package entry
+
+import (
+	bytomsdk "github.com/vapor-sdk/bytom"
+	"github.com/vapor-sdk/util"
+	vaporsdk "github.com/vapor-sdk/vapor"
+)
+
+// ValidateRawTx validate raw transaction for bytom and vapor offline in the
+// synthetic block of opts and return the util.ValidationResult as JSON, the
+// returned error is a *util.DecodeError
+func ValidateRawTx(chainName, rawTransaction string, opts util.ValidateOptions) ([]byte, error) {
+	switch chainName {
+	case "bytom":
+		return bytomsdk.BytomValidateRawTx(rawTransaction, opts)
+	case "vapor":
+		return vaporsdk.VaporValidateRawTx(rawTransaction, opts)
+	default:
+		return nil, unsupportedChainError(chainName)
+	}
+}
@@ -0,0 +1,11 @@
+package util
+
+// Address is a decoded segwit address. Type is ScriptP2WPKH or ScriptP2WSH,
+// WitnessProgram is the public key hash or script hash and ControlProgram is
+// the program paying to the address.
+type Address struct {
+	Address        string `json:"address"`
+	Type           string `json:"type"`
+	WitnessProgram string `json:"witness_program"`
+	ControlProgram string `json:"script"`
+}
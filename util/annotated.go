@@ -1,31 +1,47 @@
 package util
 
+import "encoding/json"
+
 // Transaction is the annotated transaction
 type Transaction struct {
-	TxID      string            `json:"hash"`
-	Version   int64             `json:"version"`
-	Size      int64             `json:"size"`
-	TimeRange int64             `json:"time_range"`
-	Inputs    []AnnotatedInput  `json:"inputs"`
-	Outputs   []AnnotatedOutput `json:"outputs"`
-	Fee       int64             `json:"fee"`
+	TxID        string            `json:"hash"`
+	Version     int64             `json:"version"`
+	Size        int64             `json:"size"`
+	TimeRange   int64             `json:"time_range"`
+	Inputs      []AnnotatedInput  `json:"inputs"`
+	Outputs     []AnnotatedOutput `json:"outputs"`
+	Fee         uint64            `json:"fee"`
+	FeeOverflow bool              `json:"fee_overflow,omitempty"`
 }
 
 // AnnotatedInput means an annotated transaction input.
 type AnnotatedInput struct {
-	Type             string   `json:"type"`
-	InputID          string   `json:"input_id"`
-	AssetID          string   `json:"asset"`
-	Amount           int64    `json:"amount"`
-	ControlProgram   string   `json:"script,omitempty"`
-	Address          string   `json:"address,omitempty"`
-	IssuanceProgram  string   `json:"issuance_program,omitempty"`
-	AssetDefinition  string   `json:"asset_definition,omitempty"`
-	SpentOutputID    string   `json:"spent_output_id,omitempty"`
-	Arbitrary        string   `json:"arbitrary,omitempty"`
-	WitnessArguments []string `json:"arguments,omitempty"`
-	Vote             string   `json:"vote,omitempty"`
-	SignData         string   `json:"sign_data,omitempty"`
+	Type                  string          `json:"type"`
+	InputID               string          `json:"input_id"`
+	AssetID               string          `json:"asset"`
+	Amount                uint64          `json:"amount"`
+	ControlProgram        string          `json:"script,omitempty"`
+	ScriptAsm             string          `json:"script_asm,omitempty"`
+	ScriptType            string          `json:"script_type,omitempty"`
+	Address               string          `json:"address,omitempty"`
+	IssuanceProgram       string          `json:"issuance_program,omitempty"`
+	IssuanceProgramAsm    string          `json:"issuance_program_asm,omitempty"`
+	IssuanceProgramType   string          `json:"issuance_program_type,omitempty"`
+	AssetDefinition       string          `json:"asset_definition,omitempty"`
+	ParsedAssetDefinition json.RawMessage `json:"parsed_asset_definition,omitempty"`
+	IssuanceVMVersion     uint64          `json:"issuance_vm_version,omitempty"`
+	OpenFederationIssue   *bool           `json:"open_federation_issue,omitempty"`
+	SpentOutputID         string          `json:"spent_output_id,omitempty"`
+	SourceID              string          `json:"source_id,omitempty"`
+	SourcePosition        uint64          `json:"source_position,omitempty"`
+	Nonce                 string          `json:"nonce,omitempty"`
+	Arbitrary             string          `json:"arbitrary,omitempty"`
+	WitnessArguments      []string        `json:"arguments,omitempty"`
+	Vote                  string          `json:"vote,omitempty"`
+	SignData              string          `json:"sign_data,omitempty"`
+	SignatureStatus       string          `json:"signature_status,omitempty"`
+	SignatureError        string          `json:"signature_error,omitempty"`
+	Order                 *Order          `json:"order,omitempty"`
 }
 
 // AnnotatedOutput means an annotated transaction output.
@@ -34,8 +50,12 @@ type AnnotatedOutput struct {
 	OutputID       string `json:"utxo_id"`
 	Position       int    `json:"position"`
 	AssetID        string `json:"asset"`
-	Amount         int64  `json:"amount"`
+	Amount         uint64 `json:"amount"`
 	ControlProgram string `json:"script"`
+	ScriptAsm      string `json:"script_asm,omitempty"`
+	ScriptType     string `json:"script_type,omitempty"`
 	Address        string `json:"address,omitempty"`
 	Vote           string `json:"vote,omitempty"`
+	RetireComment  string `json:"retire_comment,omitempty"`
+	Order          *Order `json:"order,omitempty"`
 }
@@ -0,0 +1,67 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// BlockMode selects which parts of a block are annotated, it mirrors the
+// serialization flags used by types.Block.
+type BlockMode string
+
+// pre-define block modes
+const (
+	BlockModeFull         BlockMode = "full"
+	BlockModeHeader       BlockMode = "header"
+	BlockModeTransactions BlockMode = "transactions"
+)
+
+// Check returns an error when m is not a pre-defined block mode, the empty
+// mode is BlockModeFull.
+func (m BlockMode) Check() error {
+	switch m {
+	case "", BlockModeFull, BlockModeHeader, BlockModeTransactions:
+		return nil
+	}
+	return fmt.Errorf("unsupported block mode %q", string(m))
+}
+
+// Block is the annotated block
+type Block struct {
+	Header       *BlockHeader   `json:"header,omitempty"`
+	Transactions []*Transaction `json:"transactions,omitempty"`
+}
+
+// BlockHeader is the annotated block header. Nonce and Bits only exist for
+// bytom blocks, Witness only exists for vapor blocks.
+type BlockHeader struct {
+	Hash                   string   `json:"hash"`
+	Version                uint64   `json:"version"`
+	Height                 uint64   `json:"height"`
+	PreviousBlockHash      string   `json:"previous_block_hash"`
+	Timestamp              uint64   `json:"timestamp"`
+	TransactionsMerkleRoot string   `json:"transaction_merkle_root"`
+	TransactionStatusHash  string   `json:"transaction_status_hash"`
+	Nonce                  *uint64  `json:"nonce,omitempty"`
+	Bits                   *uint64  `json:"bits,omitempty"`
+	Witness                []string `json:"witness,omitempty"`
+}
+
+type stringAmountBlock struct {
+	*Block
+	Transactions []*stringAmountTransaction `json:"transactions,omitempty"`
+}
+
+// MarshalBlock encodes block as JSON, amounts are rendered as in
+// MarshalTransaction.
+func MarshalBlock(block *Block, opts DecodeOptions) ([]byte, error) {
+	if !opts.StringAmounts {
+		return json.Marshal(block)
+	}
+
+	sblock := &stringAmountBlock{Block: block}
+	for _, tx := range block.Transactions {
+		sblock.Transactions = append(sblock.Transactions, newStringAmountTransaction(tx))
+	}
+	return json.Marshal(sblock)
+}
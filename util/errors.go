@@ -0,0 +1,257 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// ErrorKind classifies why a raw transaction could not be decoded.
+type ErrorKind string
+
+// pre-define error kinds
+const (
+	ErrKindUnsupportedChain   ErrorKind = "unsupported_chain"
+	ErrKindUnsupportedNetwork ErrorKind = "unsupported_network"
+	ErrKindUnsupportedMode    ErrorKind = "unsupported_block_mode"
+	ErrKindInvalidHex         ErrorKind = "invalid_hex"
+	ErrKindMalformedTx        ErrorKind = "malformed_transaction"
+	ErrKindMalformedBlock     ErrorKind = "malformed_block"
+	ErrKindFee                ErrorKind = "fee_calculation"
+	ErrKindMarshal            ErrorKind = "marshal"
+	ErrKindInvalidTx          ErrorKind = "invalid_transaction"
+	ErrKindSign               ErrorKind = "sign"
+	ErrKindInvalidScript      ErrorKind = "invalid_script"
+	ErrKindInvalidAddress     ErrorKind = "invalid_address"
+	ErrKindInvalidKey         ErrorKind = "invalid_key"
+	ErrKindInvalidMnemonic    ErrorKind = "invalid_mnemonic"
+	ErrKindInvalidHeader      ErrorKind = "invalid_header"
+	ErrKindInvalidWitness     ErrorKind = "invalid_witness"
+)
+
+// DecodeError is the error returned when a raw transaction can not be decoded.
+// Offset is the byte offset in the serialized transaction where parsing
+// failed, or -1 when the failure is not tied to a position.
+type DecodeError struct {
+	Kind   ErrorKind
+	Chain  string
+	Offset int
+	Err    error
+}
+
+// NewDecodeError returns a DecodeError which is not tied to a byte offset.
+func NewDecodeError(kind ErrorKind, chain string, err error) *DecodeError {
+	return &DecodeError{Kind: kind, Chain: chain, Offset: -1, Err: err}
+}
+
+// Error satisfies the error interface.
+func (e *DecodeError) Error() string {
+	msg := fmt.Sprintf("%s: %s", e.Chain, e.Kind)
+	if e.Offset >= 0 {
+		msg = fmt.Sprintf("%s at offset %d", msg, e.Offset)
+	}
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+// Unwrap returns the underlying cause.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// errorEnvelope is the JSON shape of a DecodeError.
+type errorEnvelope struct {
+	Error struct {
+		Kind    ErrorKind `json:"kind"`
+		Chain   string    `json:"chain"`
+		Offset  int       `json:"offset"`
+		Message string    `json:"message"`
+	} `json:"error"`
+}
+
+// MarshalErrorEnvelope renders err as a JSON object of the form
+// {"error": {"kind", "chain", "offset", "message"}}. Errors that are not a
+// DecodeError are reported with an empty kind.
+func MarshalErrorEnvelope(err error) []byte {
+	var envelope errorEnvelope
+	envelope.Error.Offset = -1
+	envelope.Error.Message = err.Error()
+	if decodeErr, ok := err.(*DecodeError); ok {
+		envelope.Error.Kind = decodeErr.Kind
+		envelope.Error.Chain = decodeErr.Chain
+		envelope.Error.Offset = decodeErr.Offset
+		if decodeErr.Err != nil {
+			envelope.Error.Message = decodeErr.Err.Error()
+		}
+	}
+
+	data, _ := json.Marshal(envelope)
+	return data
+}
+
+// NewRawTxError classifies a failure of types.Tx.UnmarshalText. The hex text
+// is checked first, then decodeTx is used to probe the wire framing shared by
+// bytom and vapor transactions for the element which can not be decoded.
+func NewRawTxError(chain, rawTransaction string, cause error, decodeTx func(hexTx []byte) error) *DecodeError {
+	if offset := invalidHexOffset(rawTransaction); offset >= 0 {
+		return &DecodeError{Kind: ErrKindInvalidHex, Chain: chain, Offset: offset, Err: cause}
+	}
+
+	b, _ := hex.DecodeString(rawTransaction)
+	return &DecodeError{Kind: ErrKindMalformedTx, Chain: chain, Offset: malformedTxOffset(b, decodeTx), Err: cause}
+}
+
+// NewRawBlockError classifies a failure of types.Block.UnmarshalText, only hex
+// errors carry a byte offset.
+func NewRawBlockError(chain, rawBlock string, cause error) *DecodeError {
+	if offset := invalidHexOffset(rawBlock); offset >= 0 {
+		return &DecodeError{Kind: ErrKindInvalidHex, Chain: chain, Offset: offset, Err: cause}
+	}
+	return NewDecodeError(ErrKindMalformedBlock, chain, cause)
+}
+
+// invalidHexOffset returns the byte offset of the first invalid hex character,
+// the decoded length for an odd-length string, or -1 if s is valid hex.
+func invalidHexOffset(s string) int {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return i / 2
+		}
+	}
+	if len(s)%2 == 1 {
+		return len(s) / 2
+	}
+	return -1
+}
+
+// serRequired is the only serialization flag accepted by both chains.
+const serRequired = 0x7
+
+// txFraming is a cursor over the outer framing of a serialized transaction:
+// serialization flags, version, time range, then a varint31-prefixed list of
+// inputs and outputs, each made of an asset version varint and two varstr31.
+type txFraming struct {
+	buf []byte
+	pos int
+}
+
+func (f *txFraming) readUvarint() bool {
+	_, n := binary.Uvarint(f.buf[f.pos:])
+	if n <= 0 {
+		return false
+	}
+	f.pos += n
+	return true
+}
+
+func (f *txFraming) readVarstr() bool {
+	l, n := binary.Uvarint(f.buf[f.pos:])
+	if n <= 0 || l > uint64(len(f.buf)-f.pos-n) {
+		return false
+	}
+	f.pos += n + int(l)
+	return true
+}
+
+// readEntry reads one input or output and reports its [start, end) range.
+func (f *txFraming) readEntry() (int, int, bool) {
+	start := f.pos
+	if !f.readUvarint() || !f.readVarstr() || !f.readVarstr() {
+		return start, f.pos, false
+	}
+	return start, f.pos, true
+}
+
+// malformedTxOffset walks the framing of b and returns the offset of the first
+// element that fails to decode. Each input and output is probed by decoding a
+// transaction made of the framing prefix plus the entries read so far. When
+// the whole framing decodes the failing element is unknown and -1 is returned.
+func malformedTxOffset(b []byte, decodeTx func(hexTx []byte) error) int {
+	f := &txFraming{buf: b}
+	if len(b) == 0 {
+		return 0
+	}
+
+	f.pos = 1
+	for i := 0; i < 2; i++ {
+		if start := f.pos; !f.readUvarint() {
+			return start
+		}
+	}
+
+	header := b[:f.pos]
+	if decodeTx(hexConcat(header, uvarintBytes(0), uvarintBytes(0))) != nil {
+		if b[0] != serRequired {
+			return 0
+		}
+		return 1
+	}
+
+	countStart := f.pos
+	numInputs, n := binary.Uvarint(b[f.pos:])
+	if n <= 0 {
+		return countStart
+	}
+	f.pos += n
+
+	inputsStart := f.pos
+	for i := uint64(0); i < numInputs; i++ {
+		start, end, ok := f.readEntry()
+		if !ok {
+			return start
+		}
+
+		probe := hexConcat(header, uvarintBytes(i+1), b[inputsStart:end], uvarintBytes(0))
+		if decodeTx(probe) != nil {
+			return start
+		}
+	}
+
+	inputs := b[countStart:f.pos]
+	countStart = f.pos
+	numOutputs, n := binary.Uvarint(b[f.pos:])
+	if n <= 0 {
+		return countStart
+	}
+	f.pos += n
+
+	outputsStart := f.pos
+	for i := uint64(0); i < numOutputs; i++ {
+		start, end, ok := f.readEntry()
+		if !ok {
+			return start
+		}
+
+		probe := hexConcat(header, inputs, uvarintBytes(i+1), b[outputsStart:end])
+		if decodeTx(probe) != nil {
+			return start
+		}
+	}
+
+	if f.pos == len(b) {
+		return -1
+	}
+	return f.pos
+}
+
+func uvarintBytes(v uint64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return buf[:binary.PutUvarint(buf, v)]
+}
+
+// hexConcat joins parts and returns them hex encoded, ready for UnmarshalText.
+func hexConcat(parts ...[]byte) []byte {
+	var buf bytes.Buffer
+	for _, p := range parts {
+		buf.Write(p)
+	}
+
+	b := make([]byte, hex.EncodedLen(buf.Len()))
+	hex.Encode(b, buf.Bytes())
+	return b
+}
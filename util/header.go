@@ -0,0 +1,62 @@
+package util
+
+// HeaderChainRequest is the JSON request to follow a header chain from a
+// trusted checkpoint. Checkpoint is the consecutive raw headers which are
+// trusted without checks, Seed is the seed of the first of them. Headers are
+// checked and added in order, they may extend any known header so competing
+// forks can be given together.
+type HeaderChainRequest struct {
+	Checkpoint []string `json:"checkpoint"`
+	Seed       string   `json:"seed,omitempty"`
+	Headers    []string `json:"headers"`
+}
+
+// HeaderChainResult is the status of each header of a HeaderChainRequest and
+// the tip of the heaviest chain. WorkSum is the decimal work of the chain
+// from the first checkpoint header.
+type HeaderChainResult struct {
+	Headers    []HeaderStatus `json:"headers"`
+	BestHash   string         `json:"best_hash"`
+	BestHeight uint64         `json:"best_height"`
+	WorkSum    string         `json:"work_sum"`
+}
+
+// HeaderStatus tells whether a header was added to the chain, Error tells why
+// it was rejected.
+type HeaderStatus struct {
+	Hash   string `json:"hash"`
+	Height uint64 `json:"height"`
+	Valid  bool   `json:"valid"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BlockWitnessRequest is the JSON request to verify the witness of a vapor
+// block, XPubs are the consensus nodes of the block in their order.
+// Threshold is the number of valid signatures required, more than two thirds
+// of the nodes when it is 0.
+type BlockWitnessRequest struct {
+	RawBlock  string   `json:"raw_block"`
+	XPubs     []string `json:"xpubs"`
+	Threshold int      `json:"threshold,omitempty"`
+}
+
+// BlockWitnessResult is the signature of each consensus node found in the
+// witness of a block. ThresholdReached tells whether the valid signatures
+// reach Threshold.
+type BlockWitnessResult struct {
+	Hash             string          `json:"hash"`
+	Height           uint64          `json:"height"`
+	Signatures       []NodeSignature `json:"signatures"`
+	SignCount        int             `json:"sign_count"`
+	Threshold        int             `json:"threshold"`
+	ThresholdReached bool            `json:"threshold_reached"`
+}
+
+// NodeSignature is the signature of the consensus node of Order over the
+// block hash, Status is SignatureValid, SignatureInvalid or SignatureMissing.
+type NodeSignature struct {
+	Order     int    `json:"order"`
+	XPub      string `json:"xpub"`
+	Signature string `json:"signature,omitempty"`
+	Status    string `json:"status"`
+}
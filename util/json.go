@@ -0,0 +1,117 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// stringAmountTransaction shadows the amount fields of Transaction so they
+// are encoded as decimal strings.
+type stringAmountTransaction struct {
+	*Transaction
+	Inputs  []stringAmountInput  `json:"inputs"`
+	Outputs []stringAmountOutput `json:"outputs"`
+	Fee     string               `json:"fee"`
+}
+
+type stringAmountInput struct {
+	*AnnotatedInput
+	Amount string `json:"amount"`
+}
+
+type stringAmountOutput struct {
+	*AnnotatedOutput
+	Amount string `json:"amount"`
+}
+
+// MarshalTransaction encodes tx as JSON. Amounts and the fee are JSON numbers
+// unless opts.StringAmounts is set, in which case they are decimal strings so
+// that clients with float64 numbers, such as JavaScript, keep full precision.
+func MarshalTransaction(tx *Transaction, opts DecodeOptions) ([]byte, error) {
+	if !opts.StringAmounts {
+		return json.Marshal(tx)
+	}
+	return json.Marshal(newStringAmountTransaction(tx))
+}
+
+func newStringAmountTransaction(tx *Transaction) *stringAmountTransaction {
+	stx := &stringAmountTransaction{
+		Transaction: tx,
+		Inputs:      make([]stringAmountInput, len(tx.Inputs)),
+		Outputs:     make([]stringAmountOutput, len(tx.Outputs)),
+		Fee:         strconv.FormatUint(tx.Fee, 10),
+	}
+	for i := range tx.Inputs {
+		stx.Inputs[i] = stringAmountInput{AnnotatedInput: &tx.Inputs[i], Amount: strconv.FormatUint(tx.Inputs[i].Amount, 10)}
+	}
+	for i := range tx.Outputs {
+		stx.Outputs[i] = stringAmountOutput{AnnotatedOutput: &tx.Outputs[i], Amount: strconv.FormatUint(tx.Outputs[i].Amount, 10)}
+	}
+	return stx
+}
+
+// the plain types have the fields but not the methods of the annotated types,
+// so that UnmarshalJSON can decode into them without recursion
+type (
+	plainTransaction Transaction
+	plainInput       AnnotatedInput
+	plainOutput      AnnotatedOutput
+)
+
+// UnmarshalJSON decodes a transaction whose fee is a JSON number or, as
+// rendered with DecodeOptions.StringAmounts, a decimal string.
+func (tx *Transaction) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		*plainTransaction
+		Fee json.Number `json:"fee"`
+	}{plainTransaction: (*plainTransaction)(tx)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	return setAmount(&tx.Fee, "fee", aux.Fee)
+}
+
+// UnmarshalJSON decodes an input whose amount is a JSON number or a decimal
+// string.
+func (in *AnnotatedInput) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		*plainInput
+		Amount json.Number `json:"amount"`
+	}{plainInput: (*plainInput)(in)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	return setAmount(&in.Amount, "amount", aux.Amount)
+}
+
+// UnmarshalJSON decodes an output whose amount is a JSON number or a decimal
+// string.
+func (out *AnnotatedOutput) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		*plainOutput
+		Amount json.Number `json:"amount"`
+	}{plainOutput: (*plainOutput)(out)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	return setAmount(&out.Amount, "amount", aux.Amount)
+}
+
+// setAmount parses the decimal amount of field into dst, which is left as is
+// when the amount is missing
+func setAmount(dst *uint64, field string, n json.Number) error {
+	if n == "" {
+		return nil
+	}
+
+	amount, err := strconv.ParseUint(string(n), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %v", field, string(n), err)
+	}
+	*dst = amount
+	return nil
+}
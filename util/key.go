@@ -0,0 +1,200 @@
+package util
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/bytom/bytom/crypto/ed25519/chainkd"
+)
+
+// the BIP44 purpose and coin type of the Bytom account layout, shared by the
+// Bytom and Vapor wallets
+const (
+	BIP44Purpose  = 44
+	BIP44CoinType = 153
+)
+
+// Key is an extended key pair, XPrv is empty when only the public half is
+// known.
+type Key struct {
+	XPrv string `json:"xprv,omitempty"`
+	XPub string `json:"xpub"`
+}
+
+// DeriveRequest is the JSON request to derive the keys of Paths from the
+// extended private or public key.
+type DeriveRequest struct {
+	Key
+	Paths   []string `json:"paths"`
+	Network string   `json:"network,omitempty"`
+}
+
+// DerivedKey is the key at Path. XPrv is only known when deriving from an
+// extended private key. Key is the KeyID of the builders: the xpub of the last
+// hardened step and the rest of the path, SigningKey is the matching XPrvKey
+// of the signers. ControlProgram and Address are the P2WPKH program and
+// address of PublicKey.
+type DerivedKey struct {
+	Path           string   `json:"path"`
+	XPrv           string   `json:"xprv,omitempty"`
+	XPub           string   `json:"xpub"`
+	PublicKey      string   `json:"public_key"`
+	Key            KeyID    `json:"key"`
+	SigningKey     *XPrvKey `json:"signing_key,omitempty"`
+	ControlProgram string   `json:"script"`
+	Address        string   `json:"address"`
+}
+
+// GenerateKey returns a new root key from the entropy of r, crypto/rand is
+// used when r is nil.
+func GenerateKey(r io.Reader) (*Key, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+
+	xprv, xpub, err := chainkd.NewXKeys(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{XPrv: xprv.String(), XPub: xpub.String()}, nil
+}
+
+// SeedKey returns the root key of seed, the same seed always gives the same
+// key.
+func SeedKey(seed []byte) (*Key, error) {
+	if len(seed) == 0 {
+		return nil, errors.New("empty seed")
+	}
+
+	xprv := chainkd.RootXPrv(seed)
+	return &Key{XPrv: xprv.String(), XPub: xprv.XPub().String()}, nil
+}
+
+// AccountPath returns the path of an address of the Bytom account layout,
+// m/purpose/coin_type/account/change/address_index. Like the Bytom wallet no
+// step is hardened.
+func AccountPath(account uint32, change bool, index uint32) string {
+	changeIndex := 0
+	if change {
+		changeIndex = 1
+	}
+	return fmt.Sprintf("m/%d/%d/%d/%d/%d", BIP44Purpose, BIP44CoinType, account, changeIndex, index)
+}
+
+// pathStep is a step of a derivation path, the selector is the 4-byte little
+// endian index like the selectors of the Bytom wallet.
+type pathStep struct {
+	selector []byte
+	hardened bool
+}
+
+// parsePath parses a path of decimal indexes separated by '/', such as
+// m/44/153/1/0/2. The leading m is optional and an index ending with ' or h is
+// a hardened step.
+func parsePath(path string) ([]pathStep, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "m"), "/")
+	if path == "" {
+		return nil, nil
+	}
+
+	var steps []pathStep
+	for _, elem := range strings.Split(path, "/") {
+		hardened := strings.HasSuffix(elem, "'") || strings.HasSuffix(elem, "h") || strings.HasSuffix(elem, "H")
+		if hardened {
+			elem = elem[:len(elem)-1]
+		}
+
+		index, err := strconv.ParseUint(elem, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path step %q", elem)
+		}
+
+		selector := make([]byte, 4)
+		binary.LittleEndian.PutUint32(selector, uint32(index))
+		steps = append(steps, pathStep{selector: selector, hardened: hardened})
+	}
+	return steps, nil
+}
+
+// publicPath returns the selectors of a derivation path without hardened
+// steps
+func publicPath(path string) ([][]byte, error) {
+	steps, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var selectors [][]byte
+	for _, step := range steps {
+		if step.hardened {
+			return nil, errors.New("hardened step in a public derivation path")
+		}
+		selectors = append(selectors, step.selector)
+	}
+	return selectors, nil
+}
+
+// DeriveKey derives the key at path from key, from its xprv when known or
+// else from its xpub, which can not derive hardened steps. ControlProgram and
+// Address are left to the chain.
+func DeriveKey(key Key, path string) (*DerivedKey, error) {
+	if key.XPrv == "" {
+		var xpub chainkd.XPub
+		if err := xpub.UnmarshalText([]byte(key.XPub)); err != nil {
+			return nil, fmt.Errorf("invalid xpub: %v", err)
+		}
+
+		selectors, err := publicPath(path)
+		if err != nil {
+			return nil, err
+		}
+
+		child := xpub.Derive(selectors)
+		return &DerivedKey{
+			Path:      path,
+			XPub:      child.String(),
+			PublicKey: hex.EncodeToString(child.PublicKey()),
+			Key:       NewKeyID(xpub, selectors),
+		}, nil
+	}
+
+	var xprv chainkd.XPrv
+	if err := xprv.UnmarshalText([]byte(key.XPrv)); err != nil {
+		return nil, fmt.Errorf("invalid xprv: %v", err)
+	}
+	if key.XPub != "" && key.XPub != xprv.XPub().String() {
+		return nil, errors.New("xpub does not match xprv")
+	}
+
+	steps, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	anchor, child := xprv, xprv
+	var selectors [][]byte
+	for _, step := range steps {
+		child = child.Child(step.selector, step.hardened)
+		if step.hardened {
+			anchor, selectors = child, nil
+			continue
+		}
+		selectors = append(selectors, step.selector)
+	}
+
+	keyID := NewKeyID(anchor.XPub(), selectors)
+	return &DerivedKey{
+		Path:       path,
+		XPrv:       child.String(),
+		XPub:       child.XPub().String(),
+		PublicKey:  hex.EncodeToString(child.XPub().PublicKey()),
+		Key:        keyID,
+		SigningKey: &XPrvKey{XPrv: anchor.String(), DerivationPath: keyID.DerivationPath},
+	}, nil
+}
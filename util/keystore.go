@@ -0,0 +1,533 @@
+package util
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytom/bytom/crypto"
+	"github.com/bytom/bytom/crypto/ed25519/chainkd"
+	"github.com/bytom/bytom/crypto/scrypt"
+)
+
+// the key file format of the Bytom node
+const (
+	KeyFileType    = "bytom_kd"
+	keyFileVersion = 1
+	keyCipher      = "aes-128-ctr"
+	keyKDF         = "scrypt"
+	scryptR        = 8
+	scryptDKLen    = 32
+)
+
+// the scrypt parameters of the Bytom node, the light ones are faster and
+// weaker
+const (
+	StandardScryptN = 1 << 18
+	StandardScryptP = 1
+	LightScryptN    = 1 << 12
+	LightScryptP    = 6
+)
+
+// the largest scrypt parameters of a key file, the Bytom node never goes
+// beyond them and larger ones from an untrusted file would make decryption
+// use unbounded memory and CPU
+const (
+	maxScryptN = StandardScryptN
+	maxScryptR = scryptR
+	maxScryptP = LightScryptP
+)
+
+// keystore errors
+var (
+	ErrDecrypt        = errors.New("could not decrypt key with given password")
+	ErrKeyNotFound    = errors.New("key not found")
+	ErrDuplicateKey   = errors.New("key already exists")
+	ErrInvalidAlias   = errors.New("invalid key alias")
+	ErrDuplicateAlias = errors.New("duplicate key alias")
+)
+
+// EncryptedKey is the JSON key file of the Bytom node, an xprv encrypted with
+// a key derived from a password by scrypt.
+type EncryptedKey struct {
+	Crypto  KeyCrypto `json:"crypto"`
+	ID      string    `json:"id"`
+	Type    string    `json:"type"`
+	Version int       `json:"version"`
+	Alias   string    `json:"alias"`
+	XPub    string    `json:"xpub"`
+}
+
+// KeyCrypto is the cipher text of a key file. The first half of the scrypt
+// key encrypts the xprv with AES-128-CTR, MAC is the SHA3-256 of the second
+// half and the cipher text.
+type KeyCrypto struct {
+	Cipher       string       `json:"cipher"`
+	CipherText   string       `json:"ciphertext"`
+	CipherParams CipherParams `json:"cipherparams"`
+	KDF          string       `json:"kdf"`
+	KDFParams    ScryptParams `json:"kdfparams"`
+	MAC          string       `json:"mac"`
+}
+
+// CipherParams is the initialization vector of the cipher.
+type CipherParams struct {
+	IV string `json:"iv"`
+}
+
+// ScryptParams are the parameters deriving the key of a key file.
+type ScryptParams struct {
+	N     int    `json:"n"`
+	R     int    `json:"r"`
+	P     int    `json:"p"`
+	DKLen int    `json:"dklen"`
+	Salt  string `json:"salt"`
+}
+
+// EncryptKey encrypts xprv with password into a key file named alias.
+func EncryptKey(xprv chainkd.XPrv, alias, password string, scryptN, scryptP int) (*EncryptedKey, error) {
+	id, err := newKeyID()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &EncryptedKey{ID: id, Type: KeyFileType, Version: keyFileVersion, Alias: alias, XPub: xprv.XPub().String()}
+	if err := key.encrypt(xprv, password, scryptN, scryptP); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// DecryptKey returns the xprv of a key file, ErrDecrypt when the password is
+// wrong.
+func DecryptKey(key *EncryptedKey, password string) (chainkd.XPrv, error) {
+	var xprv chainkd.XPrv
+	if key.Version != keyFileVersion {
+		return xprv, fmt.Errorf("unsupported key file version %d", key.Version)
+	}
+	if key.Crypto.Cipher != keyCipher {
+		return xprv, fmt.Errorf("unsupported cipher %s", key.Crypto.Cipher)
+	}
+	if key.Crypto.KDF != keyKDF {
+		return xprv, fmt.Errorf("unsupported kdf %s", key.Crypto.KDF)
+	}
+
+	cipherText, err := hex.DecodeString(key.Crypto.CipherText)
+	if err != nil || len(cipherText) != len(xprv) {
+		return xprv, errors.New("invalid ciphertext")
+	}
+	iv, err := hex.DecodeString(key.Crypto.CipherParams.IV)
+	if err != nil || len(iv) != aes.BlockSize {
+		return xprv, errors.New("invalid iv")
+	}
+	mac, err := hex.DecodeString(key.Crypto.MAC)
+	if err != nil {
+		return xprv, errors.New("invalid mac")
+	}
+
+	params := key.Crypto.KDFParams
+	salt, err := hex.DecodeString(params.Salt)
+	if err != nil {
+		return xprv, errors.New("invalid salt")
+	}
+	if params.DKLen != scryptDKLen {
+		return xprv, fmt.Errorf("unsupported dklen %d", params.DKLen)
+	}
+	if err := checkScryptParams(params.N, params.R, params.P); err != nil {
+		return xprv, err
+	}
+	derivedKey, err := scrypt.Key([]byte(password), salt, params.N, params.R, params.P, params.DKLen)
+	if err != nil {
+		return xprv, err
+	}
+
+	if !bytes.Equal(crypto.Sha256(derivedKey[16:32], cipherText), mac) {
+		return xprv, ErrDecrypt
+	}
+	plainText, err := aesCTRXOR(derivedKey[:16], cipherText, iv)
+	if err != nil {
+		return xprv, err
+	}
+
+	copy(xprv[:], plainText)
+	if xprv.XPub().String() != key.XPub {
+		return xprv, errors.New("xpub does not match the decrypted key")
+	}
+	return xprv, nil
+}
+
+// encrypt sets the crypto of key to xprv encrypted with password, with a new
+// salt and iv
+func (key *EncryptedKey) encrypt(xprv chainkd.XPrv, password string, scryptN, scryptP int) error {
+	if err := checkScryptParams(scryptN, scryptR, scryptP); err != nil {
+		return err
+	}
+
+	salt := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return err
+	}
+
+	derivedKey, err := scrypt.Key([]byte(password), salt, scryptN, scryptR, scryptP, scryptDKLen)
+	if err != nil {
+		return err
+	}
+
+	cipherText, err := aesCTRXOR(derivedKey[:16], xprv[:], iv)
+	if err != nil {
+		return err
+	}
+
+	key.Crypto = KeyCrypto{
+		Cipher:       keyCipher,
+		CipherText:   hex.EncodeToString(cipherText),
+		CipherParams: CipherParams{IV: hex.EncodeToString(iv)},
+		KDF:          keyKDF,
+		KDFParams:    ScryptParams{N: scryptN, R: scryptR, P: scryptP, DKLen: scryptDKLen, Salt: hex.EncodeToString(salt)},
+		MAC:          hex.EncodeToString(crypto.Sha256(derivedKey[16:32], cipherText)),
+	}
+	return nil
+}
+
+// checkScryptParams checks the scrypt parameters are within the limits of a
+// key file
+func checkScryptParams(n, r, p int) error {
+	if n <= 1 || n > maxScryptN || r <= 0 || r > maxScryptR || p <= 0 || p > maxScryptP {
+		return fmt.Errorf("scrypt parameters n=%d r=%d p=%d out of range", n, r, p)
+	}
+	return nil
+}
+
+func aesCTRXOR(key, in, iv []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, len(in))
+	cipher.NewCTR(block, iv).XORKeyStream(out, in)
+	return out, nil
+}
+
+// newKeyID returns a random version 4 UUID
+func newKeyID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:]), nil
+}
+
+// KeyInfo is a key of a Keystore.
+type KeyInfo struct {
+	Alias string `json:"alias"`
+	XPub  string `json:"xpub"`
+	File  string `json:"file"`
+}
+
+// Keystore keeps encrypted key files in a directory, one file per key like
+// the keystore of the Bytom node. A key is only usable to sign while it is
+// unlocked.
+type Keystore struct {
+	dir     string
+	scryptN int
+	scryptP int
+
+	mu       sync.Mutex
+	unlocked map[chainkd.XPub]*unlockedKey
+
+	// afterFunc starts the timeout of an unlocked key, replaced by the tests
+	afterFunc func(d time.Duration, f func()) *time.Timer
+}
+
+type unlockedKey struct {
+	xprv  chainkd.XPrv
+	timer *time.Timer
+}
+
+// NewKeystore returns the keystore of dir, which is created if needed. New
+// key files are encrypted with the scrypt parameters scryptN and scryptP.
+func NewKeystore(dir string, scryptN, scryptP int) (*Keystore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Keystore{
+		dir:       dir,
+		scryptN:   scryptN,
+		scryptP:   scryptP,
+		unlocked:  make(map[chainkd.XPub]*unlockedKey),
+		afterFunc: time.AfterFunc,
+	}, nil
+}
+
+// Keys returns the keys of the keystore ordered by file name.
+func (ks *Keystore) Keys() ([]*KeyInfo, error) {
+	files, err := ioutil.ReadDir(ks.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []*KeyInfo{}
+	for _, file := range files {
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+
+		key, err := ks.readKey(file.Name())
+		if err != nil {
+			continue
+		}
+		keys = append(keys, &KeyInfo{Alias: key.Alias, XPub: key.XPub, File: file.Name()})
+	}
+	return keys, nil
+}
+
+// CreateKey creates a random key named alias encrypted with password.
+func (ks *Keystore) CreateKey(alias, password string) (*KeyInfo, error) {
+	xprv, err := chainkd.NewXPrv(nil)
+	if err != nil {
+		return nil, err
+	}
+	return ks.ImportKey(alias, password, xprv)
+}
+
+// ImportKey stores xprv named alias encrypted with password. Aliases are
+// trimmed and lower case, and unique like in the Bytom node.
+func (ks *Keystore) ImportKey(alias, password string, xprv chainkd.XPrv) (*KeyInfo, error) {
+	key, err := EncryptKey(xprv, alias, password, ks.scryptN, ks.scryptP)
+	if err != nil {
+		return nil, err
+	}
+	return ks.storeKey(key)
+}
+
+// ImportKeyFile stores a key file of the Bytom node after checking that
+// password decrypts it.
+func (ks *Keystore) ImportKeyFile(keyJSON []byte, password string) (*KeyInfo, error) {
+	key := &EncryptedKey{}
+	if err := json.Unmarshal(keyJSON, key); err != nil {
+		return nil, err
+	}
+	if _, err := DecryptKey(key, password); err != nil {
+		return nil, err
+	}
+	return ks.storeKey(key)
+}
+
+// ExportKey returns the xprv of a key after checking password.
+func (ks *Keystore) ExportKey(xpub chainkd.XPub, password string) (chainkd.XPrv, error) {
+	key, _, err := ks.findKey(xpub)
+	if err != nil {
+		return chainkd.XPrv{}, err
+	}
+	return DecryptKey(key, password)
+}
+
+// ExportKeyFile returns the key file of a key, it stays encrypted.
+func (ks *Keystore) ExportKeyFile(xpub chainkd.XPub) ([]byte, error) {
+	key, _, err := ks.findKey(xpub)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(key)
+}
+
+// ChangePassword re-encrypts a key with newPassword after checking
+// oldPassword.
+func (ks *Keystore) ChangePassword(xpub chainkd.XPub, oldPassword, newPassword string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	key, name, err := ks.findKey(xpub)
+	if err != nil {
+		return err
+	}
+
+	xprv, err := DecryptKey(key, oldPassword)
+	if err != nil {
+		return err
+	}
+	if err := key.encrypt(xprv, newPassword, ks.scryptN, ks.scryptP); err != nil {
+		return err
+	}
+	return ks.writeKey(name, key)
+}
+
+// Unlock decrypts a key with password and keeps it for signing until timeout
+// elapses, or until Lock when timeout is 0. Unlocking an unlocked key resets
+// its timeout.
+func (ks *Keystore) Unlock(xpub chainkd.XPub, password string, timeout time.Duration) error {
+	xprv, err := ks.ExportKey(xpub, password)
+	if err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.lock(xpub)
+	u := &unlockedKey{xprv: xprv}
+	if timeout > 0 {
+		u.timer = ks.afterFunc(timeout, func() {
+			ks.mu.Lock()
+			defer ks.mu.Unlock()
+
+			if ks.unlocked[xpub] == u {
+				ks.lock(xpub)
+			}
+		})
+	}
+	ks.unlocked[xpub] = u
+	return nil
+}
+
+// Lock forgets the decrypted key of xpub.
+func (ks *Keystore) Lock(xpub chainkd.XPub) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.lock(xpub)
+}
+
+// Unlocked reports whether the key of xpub is unlocked.
+func (ks *Keystore) Unlocked(xpub chainkd.XPub) bool {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	_, ok := ks.unlocked[xpub]
+	return ok
+}
+
+// SignFunc returns a SignFunc signing with the unlocked keys, a locked key
+// gives ErrUnknownKey.
+func (ks *Keystore) SignFunc() SignFunc {
+	return func(xpub chainkd.XPub, path [][]byte, hash [32]byte) ([]byte, error) {
+		ks.mu.Lock()
+		defer ks.mu.Unlock()
+
+		u, ok := ks.unlocked[xpub]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		return u.xprv.Derive(path).Sign(hash[:]), nil
+	}
+}
+
+func (ks *Keystore) lock(xpub chainkd.XPub) {
+	u, ok := ks.unlocked[xpub]
+	if !ok {
+		return
+	}
+
+	if u.timer != nil {
+		u.timer.Stop()
+	}
+	u.xprv = chainkd.XPrv{}
+	delete(ks.unlocked, xpub)
+}
+
+// storeKey writes a new key file after checking its alias and xpub are not
+// in the keystore
+func (ks *Keystore) storeKey(key *EncryptedKey) (*KeyInfo, error) {
+	alias := strings.ToLower(strings.TrimSpace(key.Alias))
+	if alias == "" {
+		return nil, ErrInvalidAlias
+	}
+	key.Alias = alias
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	keys, err := ks.Keys()
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		if k.XPub == key.XPub {
+			return nil, ErrDuplicateKey
+		}
+		if k.Alias == alias {
+			return nil, ErrDuplicateAlias
+		}
+	}
+
+	name := fmt.Sprintf("UTC--%s--%s", time.Now().UTC().Format("2006-01-02T15-04-05.000000000Z"), key.ID)
+	if err := ks.writeKey(name, key); err != nil {
+		return nil, err
+	}
+	return &KeyInfo{Alias: key.Alias, XPub: key.XPub, File: name}, nil
+}
+
+// findKey returns the key file of xpub and its name
+func (ks *Keystore) findKey(xpub chainkd.XPub) (*EncryptedKey, string, error) {
+	keys, err := ks.Keys()
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, k := range keys {
+		if k.XPub == xpub.String() {
+			key, err := ks.readKey(k.File)
+			return key, k.File, err
+		}
+	}
+	return nil, "", ErrKeyNotFound
+}
+
+func (ks *Keystore) readKey(name string) (*EncryptedKey, error) {
+	data, err := ioutil.ReadFile(filepath.Join(ks.dir, name))
+	if err != nil {
+		return nil, err
+	}
+
+	key := &EncryptedKey{}
+	if err := json.Unmarshal(data, key); err != nil {
+		return nil, err
+	}
+	if key.Type != KeyFileType || key.XPub == "" {
+		return nil, fmt.Errorf("%s is not a key file", name)
+	}
+	return key, nil
+}
+
+// writeKey writes a key file readable by the owner only, through a temporary
+// file so that a crash does not leave a truncated key
+func (ks *Keystore) writeKey(name string, key *EncryptedKey) error {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(ks.dir, "."+name+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(ks.dir, name))
+}
@@ -0,0 +1,216 @@
+package util
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bytom/bytom/crypto/ed25519/chainkd"
+)
+
+// fixtureKeyFile is a key file in the format of the Bytom node with its light
+// scrypt parameters. It was not exported by a node: it was encrypted with the
+// scrypt and SHA3-256 of Python's hashlib and the AES-128-CTR of openssl, with
+// a fixed salt and iv, so that DecryptKey is not only checked against
+// EncryptKey.
+const fixtureKeyFile = "UTC--2018-06-07T07-46-22.572000000Z--1f0a7c5e-3b2d-4e8f-9a6b-0c1d2e3f4a5b"
+
+func TestDecryptKeyFile(t *testing.T) {
+	keyJSON, err := ioutil.ReadFile(filepath.Join("testdata", fixtureKeyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := &EncryptedKey{}
+	if err := json.Unmarshal(keyJSON, key); err != nil {
+		t.Fatal(err)
+	}
+	xprv, err := DecryptKey(key, "bytom fixture password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "888dceb88194d193092d1d2ac3f0ee5f7e7a6c26c6f75e9588e6a91db12a9f58a45c8756acfe23d817c3049194aa2dc14eee0cd2ca029e232eca4d4f3256d3f5"; xprv.String() != want {
+		t.Errorf("got xprv %s, want %s", xprv, want)
+	}
+	if _, err := DecryptKey(key, "wrong"); err != ErrDecrypt {
+		t.Errorf("got error %v for a wrong password, want %v", err, ErrDecrypt)
+	}
+
+	// the key file is listed by a keystore of its directory
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, fixtureKeyFile), keyJSON, 0600); err != nil {
+		t.Fatal(err)
+	}
+	ks, err := NewKeystore(dir, LightScryptN, LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := ks.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].Alias != "fixture" || keys[0].XPub != key.XPub || keys[0].File != fixtureKeyFile {
+		t.Errorf("got keys %v", keys)
+	}
+
+	// scrypt parameters beyond those of the node are rejected before the key
+	// is derived
+	cases := []struct {
+		desc    string
+		n, r, p int
+	}{
+		{desc: "n", n: maxScryptN * 2, r: scryptR, p: 1},
+		{desc: "r", n: LightScryptN, r: maxScryptR + 1, p: 1},
+		{desc: "p", n: LightScryptN, r: scryptR, p: maxScryptP + 1},
+		{desc: "zero n", n: 0, r: scryptR, p: 1},
+		{desc: "negative p", n: LightScryptN, r: scryptR, p: -1},
+	}
+	for _, c := range cases {
+		large := *key
+		large.Crypto.KDFParams.N, large.Crypto.KDFParams.R, large.Crypto.KDFParams.P = c.n, c.r, c.p
+		if _, err := DecryptKey(&large, "bytom fixture password"); err == nil || err == ErrDecrypt {
+			t.Errorf("%s: got error %v, want out of range", c.desc, err)
+		}
+	}
+	if _, err := EncryptKey(xprv, "fixture", "secret", maxScryptN*2, 1); err == nil {
+		t.Errorf("encrypted with scrypt n over the limit")
+	}
+}
+
+func TestKeystore(t *testing.T) {
+	ks, err := NewKeystore(t.TempDir(), LightScryptN, LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alice := chainkd.RootXPrv([]byte("alice"))
+	if _, err := ks.ImportKey(" Alice ", "secret", alice); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.CreateKey("ALICE", "secret"); err != ErrDuplicateAlias {
+		t.Errorf("got error %v for a duplicate alias, want %v", err, ErrDuplicateAlias)
+	}
+	if _, err := ks.ImportKey("carol", "secret", alice); err != ErrDuplicateKey {
+		t.Errorf("got error %v for a duplicate key, want %v", err, ErrDuplicateKey)
+	}
+	bob, err := ks.CreateKey("bob", "other secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := ks.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0].Alias != "alice" || keys[0].XPub != alice.XPub().String() || keys[1].XPub != bob.XPub {
+		t.Errorf("got keys %v", keys)
+	}
+
+	// the key file has the fields of the Bytom node
+	keyJSON, err := ks.ExportKeyFile(alice.XPub())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keyFile struct {
+		Crypto struct {
+			Cipher    string                 `json:"cipher"`
+			KDF       string                 `json:"kdf"`
+			KDFParams map[string]interface{} `json:"kdfparams"`
+		} `json:"crypto"`
+		Type    string `json:"type"`
+		Version int    `json:"version"`
+	}
+	if err := json.Unmarshal(keyJSON, &keyFile); err != nil {
+		t.Fatal(err)
+	}
+	if keyFile.Type != "bytom_kd" || keyFile.Version != 1 || keyFile.Crypto.Cipher != "aes-128-ctr" || keyFile.Crypto.KDF != "scrypt" || len(keyFile.Crypto.KDFParams) != 5 {
+		t.Errorf("got key file %s", keyJSON)
+	}
+
+	if _, err := ks.ExportKey(alice.XPub(), "wrong"); err != ErrDecrypt {
+		t.Errorf("got error %v for a wrong password, want %v", err, ErrDecrypt)
+	}
+	if err := ks.ChangePassword(alice.XPub(), "secret", "new secret"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.ExportKey(alice.XPub(), "secret"); err != ErrDecrypt {
+		t.Errorf("got error %v for the old password, want %v", err, ErrDecrypt)
+	}
+	if xprv, err := ks.ExportKey(alice.XPub(), "new secret"); err != nil || xprv != alice {
+		t.Errorf("got key %s (%v), want %s", xprv, err, alice)
+	}
+
+	// the exported key file imports in another keystore with its password
+	other, err := NewKeystore(t.TempDir(), LightScryptN, LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.ImportKeyFile(keyJSON, "wrong"); err != ErrDecrypt {
+		t.Errorf("got error %v importing with a wrong password, want %v", err, ErrDecrypt)
+	}
+	if info, err := other.ImportKeyFile(keyJSON, "secret"); err != nil || info.Alias != "alice" {
+		t.Errorf("got imported key %v (%v)", info, err)
+	}
+
+	// a locked key does not sign
+	path := [][]byte{{0x2c, 0, 0, 0}, {0x99, 0, 0, 0}}
+	hash := [32]byte{1}
+	sign := ks.SignFunc()
+	if _, err := sign(alice.XPub(), path, hash); err != ErrUnknownKey {
+		t.Errorf("got error %v signing with a locked key, want %v", err, ErrUnknownKey)
+	}
+
+	if err := ks.Unlock(alice.XPub(), "secret", 0); err != ErrDecrypt {
+		t.Errorf("got error %v unlocking with a wrong password, want %v", err, ErrDecrypt)
+	}
+	if err := ks.Unlock(alice.XPub(), "new secret", 0); err != nil {
+		t.Fatal(err)
+	}
+	sig, err := sign(alice.XPub(), path, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !alice.XPub().Derive(path).Verify(hash[:], sig) {
+		t.Errorf("signature of the unlocked key does not verify")
+	}
+
+	ks.Lock(alice.XPub())
+	if ks.Unlocked(alice.XPub()) {
+		t.Errorf("key is unlocked after lock")
+	}
+
+	// the timeouts are fired by the test
+	var timeouts []func()
+	ks.afterFunc = func(d time.Duration, f func()) *time.Timer {
+		if d != time.Minute {
+			t.Errorf("got timeout %v, want %v", d, time.Minute)
+		}
+		timeouts = append(timeouts, f)
+		return time.NewTimer(time.Hour)
+	}
+
+	if err := ks.Unlock(alice.XPub(), "new secret", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Unlock(alice.XPub(), "new secret", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if len(timeouts) != 2 {
+		t.Fatalf("got %d timeouts, want 2", len(timeouts))
+	}
+
+	// the timeout of the first unlock no longer locks the key
+	timeouts[0]()
+	if !ks.Unlocked(alice.XPub()) {
+		t.Errorf("key is locked by the timeout of a previous unlock")
+	}
+	timeouts[1]()
+	if ks.Unlocked(alice.XPub()) {
+		t.Errorf("key is unlocked after its timeout")
+	}
+	if _, err := sign(alice.XPub(), path, hash); err != ErrUnknownKey {
+		t.Errorf("got error %v signing after the timeout, want %v", err, ErrUnknownKey)
+	}
+}
@@ -0,0 +1,220 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// mnemonic languages, the codes of the Bytom wallet
+const (
+	LanguageEnglish            = "en"
+	LanguageChineseSimplified  = "zh_CN"
+	LanguageChineseTraditional = "zh_TW"
+	LanguageFrench             = "fr"
+	LanguageItalian            = "it"
+	LanguageJapanese           = "ja"
+	LanguageKorean             = "ko"
+	LanguageSpanish            = "es"
+)
+
+// DefaultEntropyBits is the entropy of the 12 words mnemonics of the Bytom
+// wallet.
+const DefaultEntropyBits = 128
+
+// languages lists the wordlists in the order they are tried when the
+// language of a mnemonic is not given
+var languages = []string{
+	LanguageEnglish,
+	LanguageChineseSimplified,
+	LanguageChineseTraditional,
+	LanguageFrench,
+	LanguageItalian,
+	LanguageJapanese,
+	LanguageKorean,
+	LanguageSpanish,
+}
+
+var wordlistTexts = map[string]string{
+	LanguageEnglish:            englishWords,
+	LanguageChineseSimplified:  chineseSimplifiedWords,
+	LanguageChineseTraditional: chineseTraditionalWords,
+	LanguageFrench:             frenchWords,
+	LanguageItalian:            italianWords,
+	LanguageJapanese:           japaneseWords,
+	LanguageKorean:             koreanWords,
+	LanguageSpanish:            spanishWords,
+}
+
+// wordlist is the 2048 words of a language and the index of each word
+type wordlist struct {
+	words   []string
+	indexes map[string]int
+}
+
+var (
+	wordlistsOnce sync.Once
+	wordlists     map[string]*wordlist
+)
+
+// getWordlist returns the wordlist of language, the lists are split on first
+// use
+func getWordlist(language string) (*wordlist, error) {
+	wordlistsOnce.Do(func() {
+		wordlists = make(map[string]*wordlist, len(wordlistTexts))
+		for lang, text := range wordlistTexts {
+			list := &wordlist{words: strings.Split(strings.TrimSpace(text), "\n"), indexes: make(map[string]int)}
+			for i, word := range list.words {
+				list.indexes[word] = i
+			}
+			wordlists[lang] = list
+		}
+	})
+
+	list, ok := wordlists[language]
+	if !ok {
+		return nil, fmt.Errorf("unsupported mnemonic language %q", language)
+	}
+	return list, nil
+}
+
+// GenerateMnemonic returns a mnemonic of bits of random entropy, a multiple
+// of 32 in [128, 256], DefaultEntropyBits when bits is 0.
+func GenerateMnemonic(bits int, language string) (string, error) {
+	if bits == 0 {
+		bits = DefaultEntropyBits
+	}
+	if bits < 128 || bits > 256 || bits%32 != 0 {
+		return "", fmt.Errorf("invalid entropy size %d", bits)
+	}
+
+	entropy := make([]byte, bits/8)
+	if _, err := rand.Read(entropy); err != nil {
+		return "", err
+	}
+	return NewMnemonic(entropy, language)
+}
+
+// NewMnemonic returns the BIP39 mnemonic of entropy in language. The words are
+// separated by a space, like the Bytom wallet does for every language.
+func NewMnemonic(entropy []byte, language string) (string, error) {
+	bits := len(entropy) * 8
+	if bits < 128 || bits > 256 || bits%32 != 0 {
+		return "", fmt.Errorf("invalid entropy size %d", bits)
+	}
+
+	list, err := getWordlist(language)
+	if err != nil {
+		return "", err
+	}
+
+	checksum := sha256.Sum256(entropy)
+	data := append(append([]byte{}, entropy...), checksum[0])
+	words := make([]string, (bits+bits/32)/11)
+	for i := range words {
+		words[i] = list.words[readBits(data, i*11, 11)]
+	}
+	return strings.Join(words, " "), nil
+}
+
+// MnemonicEntropy returns the entropy of a mnemonic after checking its words
+// and checksum. When language is empty the wordlists are tried in turn. The
+// words are not normalized, accented words must be in the NFKD form of the
+// BIP39 wordlists like the Bytom wallet expects.
+func MnemonicEntropy(mnemonic, language string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return nil, fmt.Errorf("invalid mnemonic length %d", len(words))
+	}
+
+	if language != "" {
+		list, err := getWordlist(language)
+		if err != nil {
+			return nil, err
+		}
+		return list.entropy(words)
+	}
+
+	for _, lang := range languages {
+		list, err := getWordlist(lang)
+		if err != nil {
+			return nil, err
+		}
+		if entropy, err := list.entropy(words); err == nil {
+			return entropy, nil
+		}
+	}
+	return nil, errors.New("mnemonic is not valid in any language")
+}
+
+// ValidateMnemonic returns nil if mnemonic is a BIP39 mnemonic of language,
+// or of any supported language when it is empty
+func ValidateMnemonic(mnemonic, language string) error {
+	_, err := MnemonicEntropy(mnemonic, language)
+	return err
+}
+
+// MnemonicSeed returns the 64-byte BIP39 seed of a mnemonic and passphrase.
+// The mnemonic is not checked, its words are joined by a single space.
+func MnemonicSeed(mnemonic, passphrase string) []byte {
+	normalized := strings.Join(strings.Fields(mnemonic), " ")
+	return pbkdf2.Key([]byte(normalized), []byte("mnemonic"+passphrase), 2048, 64, sha512.New)
+}
+
+// MnemonicKey checks a mnemonic and returns the root key of its seed, the key
+// the Bytom wallet restores from the same mnemonic and passphrase.
+func MnemonicKey(mnemonic, passphrase, language string) (*Key, error) {
+	if err := ValidateMnemonic(mnemonic, language); err != nil {
+		return nil, err
+	}
+	return SeedKey(MnemonicSeed(mnemonic, passphrase))
+}
+
+// entropy decodes words with the list and checks the checksum
+func (l *wordlist) entropy(words []string) ([]byte, error) {
+	bits := len(words) * 11
+	data := make([]byte, (bits+7)/8)
+	for i, word := range words {
+		index, ok := l.indexes[word]
+		if !ok {
+			return nil, fmt.Errorf("unknown mnemonic word %q", word)
+		}
+		writeBits(data, i*11, 11, index)
+	}
+
+	entropyBits := bits * 32 / 33
+	entropy := data[:entropyBits/8]
+	checksum := sha256.Sum256(entropy)
+	checksumBits := entropyBits / 32
+	if readBits(data, entropyBits, checksumBits) != int(checksum[0]>>uint(8-checksumBits)) {
+		return nil, errors.New("invalid mnemonic checksum")
+	}
+	return entropy, nil
+}
+
+// readBits returns the n bits of data starting at bit offset, most
+// significant bit first
+func readBits(data []byte, offset, n int) int {
+	v := 0
+	for i := offset; i < offset+n; i++ {
+		v = v<<1 | int(data[i/8]>>uint(7-i%8)&1)
+	}
+	return v
+}
+
+// writeBits sets the n bits of data starting at bit offset to v, most
+// significant bit first
+func writeBits(data []byte, offset, n, v int) {
+	for i := 0; i < n; i++ {
+		if v>>uint(n-1-i)&1 == 1 {
+			pos := offset + i
+			data[pos/8] |= 1 << uint(7-pos%8)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package util
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestMnemonicSeed(t *testing.T) {
+	cases := []struct {
+		language   string
+		entropy    string
+		mnemonic   string
+		passphrase string
+		seed       string
+	}{
+		// the English vectors of BIP39
+		{
+			language:   LanguageEnglish,
+			entropy:    "00000000000000000000000000000000",
+			mnemonic:   "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			passphrase: "TREZOR",
+			seed:       "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			language:   LanguageEnglish,
+			entropy:    "9e885d952ad362caeb4efe34a8e91bd2",
+			mnemonic:   "ozone drill grab fiber curtain grace pudding thank cruise elder eight picnic",
+			passphrase: "TREZOR",
+			seed:       "274ddc525802f7c828d8ef7ddbcdc5304e87ac3535913611fbbfa986d0c9e5476c91689f9c8a54fd55bd38606aa6a8595ad213d4c9c9f9aca3fb217069a41028",
+		},
+		{
+			language:   LanguageEnglish,
+			entropy:    "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			mnemonic:   "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo vote",
+			passphrase: "TREZOR",
+			seed:       "dd48c104698c30cfe2b6142103248622fb7bb0ff692eebb00089b32d22484e1613912f0a5b694407be899ffd31ed3992c456cdf60f5d4564b8ba3f05a69890ad",
+		},
+		// the Japanese vectors of BIP39 in NFKD form, the words are separated
+		// by ideographic spaces like the vectors
+		{
+			language:   LanguageJapanese,
+			entropy:    "00000000000000000000000000000000",
+			mnemonic:   "あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あおそ\u3099ら",
+			passphrase: "メートルカ\u3099ハ\u3099ウ\u3099ァは\u309aは\u3099く\u3099ゝ\u3099ちち\u3099十人十色",
+			seed:       "a262d6fb6122ecf45be09c50492b31f92e9beb7d9a845987a02cefda57a15f9c467a17872029a9e92299b5cbdf306e3a0ee620245cbd508959b6cb7ca637bd55",
+		},
+		{
+			language:   LanguageJapanese,
+			entropy:    "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			mnemonic:   "そつう　れきた\u3099い　ほんやく　わかす　りくつ　は\u3099いか　ろせん　やちん　そつう　れきた\u3099い　ほんやく　わかめ",
+			passphrase: "メートルカ\u3099ハ\u3099ウ\u3099ァは\u309aは\u3099く\u3099ゝ\u3099ちち\u3099十人十色",
+			seed:       "aee025cbe6ca256862f889e48110a6a382365142f7d16f2b9545285b3af64e542143a577e9c144e101a6bdca18f8d97ec3366ebf5b088b1c1af9bc31346e60d9",
+		},
+	}
+
+	for _, c := range cases {
+		entropy, _ := hex.DecodeString(c.entropy)
+		mnemonic, err := NewMnemonic(entropy, c.language)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := strings.Join(strings.Fields(c.mnemonic), " "); mnemonic != want {
+			t.Errorf("got mnemonic %s, want %s", mnemonic, want)
+		}
+
+		decoded, err := MnemonicEntropy(c.mnemonic, "")
+		if err != nil || hex.EncodeToString(decoded) != c.entropy {
+			t.Errorf("got entropy %x (%v), want %s", decoded, err, c.entropy)
+		}
+
+		if seed := MnemonicSeed(c.mnemonic, c.passphrase); hex.EncodeToString(seed) != c.seed {
+			t.Errorf("got seed %x, want %s", seed, c.seed)
+		}
+	}
+}
+
+func TestMnemonicKey(t *testing.T) {
+	// the Bytom wallet imports a mnemonic with an empty passphrase and reads
+	// the root key from its seed with chainkd.NewXKeys, giving this xpub
+	mnemonic := "legal winner thank year wave sausage worth useful legal winner thank yellow"
+	seed := "878386efb78845b3355bd15ea4d39ef97d179cb712b77d5c12b6be415fffeffe5f377ba02bf3f8544ab800b955e51fbff09828f682052a20faa6addbbddfb096"
+	xpub := "c8a8bf4d6bd7303e3b4617bb180057f90503a4df3f1c83267f7e760bc6c7ce57d56e73f4ee1f967ebfb086964589fcefbbc31cda112c6459962aa12779946eba"
+
+	if got := MnemonicSeed(mnemonic, ""); hex.EncodeToString(got) != seed {
+		t.Errorf("got seed %x, want %s", got, seed)
+	}
+	key, err := MnemonicKey(mnemonic, "", LanguageEnglish)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.XPub != xpub {
+		t.Errorf("got xpub %s, want %s", key.XPub, xpub)
+	}
+
+	// the wordlists are in NFKD form like the lists of the Bytom wallet
+	firstWords := map[string]string{
+		LanguageEnglish:            "abandon",
+		LanguageChineseSimplified:  "的",
+		LanguageChineseTraditional: "的",
+		LanguageFrench:             "abaisser",
+		LanguageItalian:            "abaco",
+		LanguageJapanese:           "あいこくしん",
+		LanguageKorean:             "\u1100\u1161\u1100\u1167\u11a8",
+		LanguageSpanish:            "a\u0301baco",
+	}
+	for language, firstWord := range firstWords {
+		mnemonic, err := GenerateMnemonic(256, language)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ValidateMnemonic(mnemonic, language); err != nil || len(strings.Fields(mnemonic)) != 24 {
+			t.Errorf("%s: generated mnemonic %s is not valid: %v", language, mnemonic, err)
+		}
+
+		mnemonic, err = NewMnemonic(make([]byte, 16), language)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if words := strings.Fields(mnemonic); words[0] != firstWord {
+			t.Errorf("%s: got first word %s, want %s", language, words[0], firstWord)
+		}
+	}
+
+	invalid := []struct {
+		mnemonic string
+		language string
+	}{
+		{mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon"},
+		{mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon"},
+		{mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon bytom"},
+		{mnemonic: mnemonic, language: LanguageFrench},
+		{mnemonic: mnemonic, language: "xx"},
+	}
+	for _, c := range invalid {
+		if _, err := MnemonicKey(c.mnemonic, "", c.language); err == nil {
+			t.Errorf("%s (%s): got no error", c.mnemonic, c.language)
+		}
+	}
+}
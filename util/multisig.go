@@ -0,0 +1,58 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/bytom/bytom/crypto/ed25519"
+)
+
+// SortKeyIDs returns the keys of a multisig account ordered by xpub, the order
+// the Bytom wallet uses for the public keys of a multisig program, so that
+// every cosigner derives the same program.
+func SortKeyIDs(keys []KeyID) ([]KeyID, error) {
+	xpubs := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		xpub, _, err := DecodeKeyID(key)
+		if err != nil {
+			return nil, err
+		}
+		xpubs[key.XPub] = xpub[:]
+	}
+
+	sorted := append([]KeyID{}, keys...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return bytes.Compare(xpubs[sorted[i].XPub], xpubs[sorted[j].XPub]) < 0
+	})
+	return sorted, nil
+}
+
+// MultiSigPublicKeys returns the public keys derived from keys, in order, for
+// an m-of-n multisig program with the given quorum.
+func MultiSigPublicKeys(keys []KeyID, quorum int) ([]ed25519.PublicKey, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("multisig needs at least one key")
+	}
+	if quorum < 1 || quorum > len(keys) {
+		return nil, fmt.Errorf("quorum %d is out of range [1, %d]", quorum, len(keys))
+	}
+
+	var pubKeys []ed25519.PublicKey
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		xpub, path, err := DecodeKeyID(key)
+		if err != nil {
+			return nil, err
+		}
+
+		pubKey := xpub.Derive(path).PublicKey()
+		if seen[string(pubKey)] {
+			return nil, fmt.Errorf("duplicate public key %x", pubKey)
+		}
+		seen[string(pubKey)] = true
+		pubKeys = append(pubKeys, pubKey)
+	}
+	return pubKeys, nil
+}
@@ -0,0 +1,32 @@
+package util
+
+// DecodeOptions tunes how a raw transaction is decoded and annotated.
+type DecodeOptions struct {
+	// Network is the chain ID used to render addresses: mainnet, testnet or
+	// solonet. An empty network means mainnet.
+	Network string
+
+	// StringAmounts renders amounts and the fee as decimal strings in JSON
+	// output instead of numbers.
+	StringAmounts bool
+
+	// VerifySignatures checks the witness arguments of every spend, veto and
+	// issue input against its sign data, see VerifyWitness.
+	VerifySignatures bool
+}
+
+// ValidateOptions sets the synthetic block an offline validation runs in, as
+// there is no chain state to take them from.
+type ValidateOptions struct {
+	// Network is the chain ID whose consensus params are used, an empty
+	// network means mainnet.
+	Network string `json:"network,omitempty"`
+
+	// BlockHeight is the height seen by the time range check and by
+	// OP_BLOCKHEIGHT.
+	BlockHeight uint64 `json:"block_height"`
+
+	// Timestamp is the timestamp of the synthetic block header, only bytom
+	// has one and vapor rejects a nonzero timestamp.
+	Timestamp uint64 `json:"timestamp"`
+}
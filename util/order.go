@@ -0,0 +1,22 @@
+package util
+
+// clauses of a magnetic contract spent by an input
+const (
+	OrderPartialTrade = "partial_trade"
+	OrderFullTrade    = "full_trade"
+	OrderCancel       = "cancel"
+)
+
+// Order is a vapor DEX order, the arguments of a P2WMC magnetic contract
+// program. The seller offers the locked asset and asks RatioNumerator of
+// RequestedAsset for every RatioDenominator of it. Clause is only set on an
+// input spending the order.
+type Order struct {
+	RequestedAsset   string `json:"requested_asset"`
+	RatioNumerator   int64  `json:"ratio_numerator"`
+	RatioDenominator int64  `json:"ratio_denominator"`
+	SellerProgram    string `json:"seller_program"`
+	SellerAddress    string `json:"seller_address,omitempty"`
+	SellerKey        string `json:"seller_key"`
+	Clause           string `json:"clause,omitempty"`
+}
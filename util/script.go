@@ -0,0 +1,34 @@
+package util
+
+// script types of a control or issuance program
+const (
+	ScriptP2WPKH      = "p2wpkh"
+	ScriptP2WSH       = "p2wsh"
+	ScriptRetire      = "retire"
+	ScriptP2WMC       = "p2wmc"
+	ScriptMultiSig    = "multisig"
+	ScriptNonStandard = "nonstandard"
+)
+
+// AssembleRequest is the JSON request to assemble the program text Source,
+// the address is encoded for Network.
+type AssembleRequest struct {
+	Source  string `json:"source"`
+	Network string `json:"network,omitempty"`
+}
+
+// Script is an assembled program. ScriptHash is the SHA3-256 hash of Program
+// and ControlProgram is the P2WSH program paying to it.
+type Script struct {
+	Program        string `json:"program"`
+	ScriptHash     string `json:"script_hash"`
+	ControlProgram string `json:"script"`
+	Address        string `json:"address"`
+}
+
+// IsMultiSigProgram reports whether program is a bare multisig program built
+// by P2SPMultiSigProgram or P2SPMultiSigProgramWithHeight.
+func IsMultiSigProgram(program []byte) bool {
+	_, _, err := parseMultiSigProgram(program)
+	return err == nil
+}
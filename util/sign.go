@@ -0,0 +1,319 @@
+package util
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/bytom/bytom/crypto/ed25519/chainkd"
+)
+
+// ErrUnknownKey is returned by a SignFunc which does not hold the requested
+// key, the signature slot is then left empty for another signer.
+var ErrUnknownKey = errors.New("signing key is not available")
+
+// SignFunc produces a signature of hash with the key derived from xpub along
+// path, it is the offline counterpart of txbuilder.SignFunc so that keys can
+// stay in an external signer.
+type SignFunc func(xpub chainkd.XPub, path [][]byte, hash [32]byte) ([]byte, error)
+
+// XPrvSignFunc returns a SignFunc which signs with the given extended private
+// keys.
+func XPrvSignFunc(xprvs ...chainkd.XPrv) SignFunc {
+	return func(xpub chainkd.XPub, path [][]byte, hash [32]byte) ([]byte, error) {
+		for _, xprv := range xprvs {
+			if xprv.XPub() == xpub {
+				return xprv.Derive(path).Sign(hash[:]), nil
+			}
+		}
+		return nil, ErrUnknownKey
+	}
+}
+
+// SigHashFunc returns the signature hash of the input at position of the raw
+// transaction of a template, or an error when there is no such input.
+type SigHashFunc func(position uint32) ([32]byte, error)
+
+// SignTemplate fills the empty signature slots of every raw_tx_signature
+// component of tpl with signFn, signatures already present are kept. The hash
+// of each input is recomputed with sigHash like txbuilder signs tpl.Hash, and
+// the template is rejected before anything is signed when a sign_data differs,
+// so that a template can not get a foreign hash signed.
+func SignTemplate(tpl *Template, sigHash SigHashFunc, signFn SignFunc) error {
+	hashes, err := CheckSignData(tpl, sigHash)
+	if err != nil {
+		return err
+	}
+
+	for i, sigInst := range tpl.SigningInstructions {
+		for _, wc := range sigInst.WitnessComponents {
+			if wc.Type != WitnessRawTxSignature {
+				continue
+			}
+			if err := wc.sign(hashes[i], signFn); err != nil {
+				return fmt.Errorf("input %d: %v", sigInst.Position, err)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckSignData recomputes the signature hash of the input of every signing
+// instruction of tpl with sigHash and returns them, the sign_data of each
+// instruction must be that hash.
+func CheckSignData(tpl *Template, sigHash SigHashFunc) ([][32]byte, error) {
+	var hashes [][32]byte
+	for _, sigInst := range tpl.SigningInstructions {
+		hash, err := sigHash(sigInst.Position)
+		if err != nil {
+			return nil, err
+		}
+		if sigInst.SignData != hex.EncodeToString(hash[:]) {
+			return nil, fmt.Errorf("sign_data of input %d is not the signature hash of the transaction", sigInst.Position)
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
+
+func (wc *WitnessComponent) sign(hash [32]byte, signFn SignFunc) error {
+	if len(wc.Signatures) < len(wc.Keys) {
+		signatures := make([]string, len(wc.Keys))
+		copy(signatures, wc.Signatures)
+		wc.Signatures = signatures
+	}
+
+	for i, key := range wc.Keys {
+		if wc.Signatures[i] != "" {
+			continue
+		}
+
+		xpub, path, err := DecodeKeyID(key)
+		if err != nil {
+			return err
+		}
+
+		signature, err := signFn(xpub, path, hash)
+		if err == ErrUnknownKey {
+			continue
+		} else if err != nil {
+			return err
+		}
+		wc.Signatures[i] = hex.EncodeToString(signature)
+	}
+	return nil
+}
+
+// SignProgress reports whether every raw_tx_signature component of tpl has
+// reached its quorum.
+func SignProgress(tpl *Template) bool {
+	for _, sigInst := range tpl.SigningInstructions {
+		if !sigInst.signed() {
+			return false
+		}
+	}
+	return true
+}
+
+func (si *SigningInstruction) signed() bool {
+	for _, wc := range si.WitnessComponents {
+		if wc.Type == WitnessRawTxSignature && wc.signedCount() < wc.Quorum {
+			return false
+		}
+	}
+	return true
+}
+
+func (wc *WitnessComponent) signedCount() (count int) {
+	for _, signature := range wc.Signatures {
+		if signature != "" {
+			count++
+		}
+	}
+	return
+}
+
+// WitnessArguments materializes the witness components into the witness
+// arguments of the input, ok is false until every signature quorum is met.
+// At most Quorum signatures are used, in key order.
+func (si *SigningInstruction) WitnessArguments() (args [][]byte, ok bool, err error) {
+	if !si.signed() {
+		return nil, false, nil
+	}
+
+	for _, wc := range si.WitnessComponents {
+		switch wc.Type {
+		case WitnessRawTxSignature:
+			var nsigs int
+			for i := 0; i < len(wc.Signatures) && nsigs < wc.Quorum; i++ {
+				if wc.Signatures[i] == "" {
+					continue
+				}
+				signature, err := hex.DecodeString(wc.Signatures[i])
+				if err != nil {
+					return nil, false, fmt.Errorf("invalid signature: %v", err)
+				}
+				args = append(args, signature)
+				nsigs++
+			}
+
+		case WitnessData:
+			value, err := hex.DecodeString(wc.Value)
+			if err != nil {
+				return nil, false, fmt.Errorf("invalid data: %v", err)
+			}
+			args = append(args, value)
+
+		default:
+			return nil, false, fmt.Errorf("unknown witness component type %q", wc.Type)
+		}
+	}
+	return args, true, nil
+}
+
+// DecodeKeyID returns the xpub and derivation path of a signing key.
+func DecodeKeyID(key KeyID) (chainkd.XPub, [][]byte, error) {
+	var xpub chainkd.XPub
+	if err := xpub.UnmarshalText([]byte(key.XPub)); err != nil {
+		return xpub, nil, fmt.Errorf("invalid xpub: %v", err)
+	}
+
+	var path [][]byte
+	for _, p := range key.DerivationPath {
+		step, err := hex.DecodeString(p)
+		if err != nil {
+			return xpub, nil, fmt.Errorf("invalid derivation_path: %v", err)
+		}
+		path = append(path, step)
+	}
+	return xpub, path, nil
+}
+
+// NewKeyID returns the KeyID of xpub and a derivation path.
+func NewKeyID(xpub chainkd.XPub, path [][]byte) KeyID {
+	key := KeyID{XPub: xpub.String(), DerivationPath: []string{}}
+	for _, p := range path {
+		key.DerivationPath = append(key.DerivationPath, hex.EncodeToString(p))
+	}
+	return key
+}
+
+// DecodeXPrvKeys returns the extended private keys of keys, to be used with
+// XPrvSignFunc, and the KeyID of each signing key.
+func DecodeXPrvKeys(keys []XPrvKey) ([]chainkd.XPrv, []KeyID, error) {
+	var xprvs []chainkd.XPrv
+	var keyIDs []KeyID
+	for _, key := range keys {
+		var xprv chainkd.XPrv
+		if err := xprv.UnmarshalText([]byte(key.XPrv)); err != nil {
+			return nil, nil, fmt.Errorf("invalid xprv: %v", err)
+		}
+
+		keyID := KeyID{XPub: xprv.XPub().String(), DerivationPath: key.DerivationPath}
+		if _, _, err := DecodeKeyID(keyID); err != nil {
+			return nil, nil, err
+		}
+		xprvs = append(xprvs, xprv)
+		keyIDs = append(keyIDs, keyID)
+	}
+	return xprvs, keyIDs, nil
+}
+
+// CombineTemplates merges the signatures of partially signed copies of the
+// same template, as produced by different cosigners, into a new template. The
+// raw transaction is taken from the first copy, sigHash computes the hashes of
+// its inputs which the sign_data of every copy must match.
+func CombineTemplates(sigHash SigHashFunc, tpls ...*Template) (*Template, error) {
+	if len(tpls) == 0 {
+		return nil, errors.New("no template to combine")
+	}
+	for i, tpl := range tpls {
+		if _, err := CheckSignData(tpl, sigHash); err != nil {
+			return nil, fmt.Errorf("template %d: %v", i, err)
+		}
+	}
+
+	combined := &Template{RawTransaction: tpls[0].RawTransaction, Fee: tpls[0].Fee}
+	for _, sigInst := range tpls[0].SigningInstructions {
+		combined.SigningInstructions = append(combined.SigningInstructions, sigInst.copy())
+	}
+
+	for i, tpl := range tpls[1:] {
+		if err := mergeSignatures(combined, tpl); err != nil {
+			return nil, fmt.Errorf("template %d: %v", i+1, err)
+		}
+	}
+	return combined, nil
+}
+
+func (si *SigningInstruction) copy() *SigningInstruction {
+	sigInst := &SigningInstruction{Position: si.Position, SignData: si.SignData, WitnessComponents: []*WitnessComponent{}}
+	for _, wc := range si.WitnessComponents {
+		component := *wc
+		component.Signatures = append([]string(nil), wc.Signatures...)
+		sigInst.WitnessComponents = append(sigInst.WitnessComponents, &component)
+	}
+	return sigInst
+}
+
+// mergeSignatures fills the empty signature slots of dst with the signatures
+// of src, both must hold the same signing instructions.
+func mergeSignatures(dst, src *Template) error {
+	if len(dst.SigningInstructions) != len(src.SigningInstructions) {
+		return errors.New("signing instructions mismatch")
+	}
+
+	for i, sigInst := range dst.SigningInstructions {
+		srcInst := src.SigningInstructions[i]
+		if sigInst.Position != srcInst.Position || sigInst.SignData != srcInst.SignData || len(sigInst.WitnessComponents) != len(srcInst.WitnessComponents) {
+			return fmt.Errorf("signing instruction of input %d mismatch", sigInst.Position)
+		}
+
+		for j, wc := range sigInst.WitnessComponents {
+			if err := wc.merge(srcInst.WitnessComponents[j]); err != nil {
+				return fmt.Errorf("input %d: %v", sigInst.Position, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (wc *WitnessComponent) merge(src *WitnessComponent) error {
+	if wc.Type != src.Type || wc.Quorum != src.Quorum || wc.Value != src.Value || len(wc.Keys) != len(src.Keys) {
+		return errors.New("witness components mismatch")
+	}
+	for i, key := range wc.Keys {
+		if key.XPub != src.Keys[i].XPub || !equalPath(key.DerivationPath, src.Keys[i].DerivationPath) {
+			return errors.New("signing keys mismatch")
+		}
+	}
+
+	if len(wc.Signatures) < len(wc.Keys) {
+		signatures := make([]string, len(wc.Keys))
+		copy(signatures, wc.Signatures)
+		wc.Signatures = signatures
+	}
+	for i, signature := range src.Signatures {
+		if i >= len(wc.Signatures) || signature == "" {
+			continue
+		}
+		if wc.Signatures[i] == "" {
+			wc.Signatures[i] = signature
+		} else if wc.Signatures[i] != signature {
+			return fmt.Errorf("conflicting signatures of key %d", i)
+		}
+	}
+	return nil
+}
+
+func equalPath(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
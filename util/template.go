@@ -0,0 +1,121 @@
+package util
+
+import "encoding/json"
+
+// witness component types of a SigningInstruction
+const (
+	WitnessRawTxSignature = "raw_tx_signature"
+	WitnessData           = "data"
+)
+
+// Template is a transaction built by the offline builders. The raw transaction
+// is unsigned or partially signed, and each input carries the instruction to
+// produce its witness arguments.
+type Template struct {
+	RawTransaction      string                `json:"raw_transaction"`
+	SigningInstructions []*SigningInstruction `json:"signing_instructions"`
+	Fee                 uint64                `json:"fee"`
+}
+
+// SigningInstruction gives directions for signing the input at Position,
+// SignData is the hash which the signatures commit to.
+type SigningInstruction struct {
+	Position          uint32              `json:"position"`
+	SignData          string              `json:"sign_data"`
+	WitnessComponents []*WitnessComponent `json:"witness_components"`
+}
+
+// WitnessComponent produces one or more witness arguments. A raw_tx_signature
+// component needs Quorum signatures of SignData from Keys, Signatures keeps
+// one slot per key. A data component contributes Value as is.
+type WitnessComponent struct {
+	Type       string   `json:"type"`
+	Quorum     int      `json:"quorum,omitempty"`
+	Keys       []KeyID  `json:"keys,omitempty"`
+	Signatures []string `json:"signatures,omitempty"`
+	Value      string   `json:"value,omitempty"`
+}
+
+// KeyID identifies a signing key as an xpub and the derivation path applied
+// to it, both hex encoded.
+type KeyID struct {
+	XPub           string   `json:"xpub"`
+	DerivationPath []string `json:"derivation_path"`
+}
+
+// UTXO is a caller supplied unspent output for the offline builders. Keys and
+// Quorum describe who controls the script, ValidHeight is the first block
+// height at which the output may be spent.
+type UTXO struct {
+	SourceID       string  `json:"source_id"`
+	SourcePosition uint64  `json:"source_position"`
+	AssetID        string  `json:"asset"`
+	Amount         uint64  `json:"amount"`
+	ControlProgram string  `json:"script"`
+	Vote           string  `json:"vote,omitempty"`
+	ValidHeight    uint64  `json:"valid_height,omitempty"`
+	Keys           []KeyID `json:"keys,omitempty"`
+	Quorum         int     `json:"quorum,omitempty"`
+}
+
+// BuildRequest is the JSON request of the offline builders. TimeRange is the
+// last block height at which the transaction is valid, 0 means no limit.
+type BuildRequest struct {
+	Actions   []json.RawMessage `json:"actions"`
+	TimeRange uint64            `json:"time_range"`
+	Network   string            `json:"network,omitempty"`
+}
+
+// AssetAmount is the asset and amount of a builder action.
+type AssetAmount struct {
+	AssetID string `json:"asset"`
+	Amount  uint64 `json:"amount"`
+}
+
+// SignRequest is the JSON request of the offline signers, either Template or
+// RawTransaction is signed with Keys.
+type SignRequest struct {
+	Template       *Template `json:"template,omitempty"`
+	RawTransaction string    `json:"raw_transaction,omitempty"`
+	Keys           []XPrvKey `json:"keys"`
+}
+
+// XPrvKey is a hex encoded extended private key and the derivation path of
+// the signing key.
+type XPrvKey struct {
+	XPrv           string   `json:"xprv"`
+	DerivationPath []string `json:"derivation_path"`
+}
+
+// SignResult is the JSON result of the offline signers, Template is only
+// returned when a template was signed.
+type SignResult struct {
+	RawTransaction string    `json:"raw_transaction"`
+	Template       *Template `json:"template,omitempty"`
+	SignComplete   bool      `json:"sign_complete"`
+}
+
+// MultiSigRequest is the JSON request to create an m-of-n multisig program,
+// the addresses are encoded for Network.
+type MultiSigRequest struct {
+	Keys    []KeyID `json:"keys"`
+	Quorum  int     `json:"quorum"`
+	Network string  `json:"network,omitempty"`
+}
+
+// MultiSig is an m-of-n multisig program. ControlProgram is the P2WSH program
+// of RedeemProgram, Keys are in the order of the public keys of RedeemProgram
+// and are the keys of a UTXO spending it.
+type MultiSig struct {
+	RedeemProgram  string  `json:"redeem_program"`
+	ControlProgram string  `json:"script"`
+	Address        string  `json:"address"`
+	Quorum         int     `json:"quorum"`
+	Keys           []KeyID `json:"keys"`
+}
+
+// CombineRequest is the JSON request to combine the partially signed copies of
+// a template.
+type CombineRequest struct {
+	Templates []*Template `json:"templates"`
+}
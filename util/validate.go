@@ -0,0 +1,32 @@
+package util
+
+// validation status of an input, see InputValidation
+const (
+	ValidationPass    = "pass"
+	ValidationFail    = "fail"
+	ValidationSkipped = "skipped"
+)
+
+// ValidationResult is the result of validating a transaction offline, Valid
+// is false when an input or a transaction level rule fails. GasUsed includes
+// the storage gas of the transaction.
+type ValidationResult struct {
+	TxID       string            `json:"hash"`
+	Valid      bool              `json:"valid"`
+	Error      string            `json:"error,omitempty"`
+	GasUsed    int64             `json:"gas_used"`
+	GasLeft    int64             `json:"gas_left"`
+	StorageGas int64             `json:"storage_gas"`
+	Inputs     []InputValidation `json:"inputs"`
+}
+
+// InputValidation is the result of running the program of one input through
+// the VM. Inputs without a program that can run offline are skipped.
+type InputValidation struct {
+	Position int    `json:"position"`
+	Type     string `json:"type"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	GasUsed  int64  `json:"gas_used"`
+	GasLeft  int64  `json:"gas_left"`
+}
@@ -0,0 +1,149 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/bytom/bytom/consensus/segwit"
+	"github.com/bytom/bytom/crypto"
+	"github.com/bytom/bytom/crypto/ed25519"
+	"github.com/bytom/bytom/protocol/vm"
+)
+
+// signature status of an input, see VerifyWitness
+const (
+	SignatureValid       = "valid"
+	SignatureInvalid     = "invalid"
+	SignatureMissing     = "missing"
+	SignatureUnsupported = "unsupported"
+)
+
+// VerifyWitness checks the witness arguments of an input locked by program
+// against its sign data and returns the signature status. P2WPKH, P2WSH
+// multisig and bare multisig programs are supported, the returned error tells
+// why the witness is not valid. The segwit programs share one format on Bytom
+// and Vapor.
+func VerifyWitness(program []byte, args [][]byte, signData [32]byte) (string, error) {
+	switch {
+	case segwit.IsP2WPKHScript(program):
+		return verifyP2WPKHWitness(program, args, signData)
+
+	case segwit.IsP2WSHScript(program):
+		return verifyP2WSHWitness(program, args, signData)
+	}
+
+	if pubKeys, quorum, err := parseMultiSigProgram(program); err == nil {
+		return verifyMultiSigWitness(pubKeys, quorum, args, signData)
+	}
+	return SignatureUnsupported, errors.New("unsupported script")
+}
+
+func verifyP2WPKHWitness(program []byte, args [][]byte, signData [32]byte) (string, error) {
+	if len(args) == 0 {
+		return SignatureMissing, errors.New("no witness arguments")
+	}
+	if len(args) != 2 {
+		return SignatureInvalid, fmt.Errorf("got %d witness arguments, want a signature and a public key", len(args))
+	}
+
+	pubHash, err := segwit.GetHashFromStandardProg(program)
+	if err != nil {
+		return SignatureInvalid, err
+	}
+
+	signature, pubKey := args[0], args[1]
+	if len(pubKey) != ed25519.PublicKeySize || !bytes.Equal(crypto.Ripemd160(pubKey), pubHash) {
+		return SignatureInvalid, errors.New("public key does not match the script")
+	}
+	if !ed25519.Verify(ed25519.PublicKey(pubKey), signData[:], signature) {
+		return SignatureInvalid, errors.New("signature does not verify")
+	}
+	return SignatureValid, nil
+}
+
+func verifyP2WSHWitness(program []byte, args [][]byte, signData [32]byte) (string, error) {
+	if len(args) == 0 {
+		return SignatureMissing, errors.New("no witness arguments")
+	}
+
+	scriptHash, err := segwit.GetHashFromStandardProg(program)
+	if err != nil {
+		return SignatureInvalid, err
+	}
+
+	redeemProgram := args[len(args)-1]
+	if !bytes.Equal(crypto.Sha256(redeemProgram), scriptHash) {
+		return SignatureInvalid, errors.New("redeem program does not match the script")
+	}
+
+	pubKeys, quorum, err := parseMultiSigProgram(redeemProgram)
+	if err != nil {
+		return SignatureUnsupported, fmt.Errorf("unsupported redeem program: %v", err)
+	}
+	return verifyMultiSigWitness(pubKeys, quorum, args[:len(args)-1], signData)
+}
+
+// verifyMultiSigWitness checks quorum signatures in the order of pubKeys, the
+// way OP_CHECKMULTISIG does.
+func verifyMultiSigWitness(pubKeys []ed25519.PublicKey, quorum int, signatures [][]byte, signData [32]byte) (string, error) {
+	if len(signatures) == 0 {
+		return SignatureMissing, errors.New("no signatures")
+	}
+	if len(signatures) < quorum {
+		return SignatureMissing, fmt.Errorf("got %d of %d signatures", len(signatures), quorum)
+	}
+	if len(signatures) > quorum {
+		return SignatureInvalid, fmt.Errorf("got %d signatures, want %d", len(signatures), quorum)
+	}
+
+	for i, signature := range signatures {
+		for len(pubKeys) > 0 && !ed25519.Verify(pubKeys[0], signData[:], signature) {
+			pubKeys = pubKeys[1:]
+		}
+		if len(pubKeys) == 0 {
+			return SignatureInvalid, fmt.Errorf("signature %d does not verify", i)
+		}
+		pubKeys = pubKeys[1:]
+	}
+	return SignatureValid, nil
+}
+
+// parseMultiSigProgram returns the public keys and quorum of a program built
+// by P2SPMultiSigProgram or P2SPMultiSigProgramWithHeight.
+func parseMultiSigProgram(program []byte) ([]ed25519.PublicKey, int, error) {
+	insts, err := vm.ParseProgram(program)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(insts) > 4 && insts[1].Op == vm.OP_BLOCKHEIGHT && insts[2].Op == vm.OP_GREATERTHAN && insts[3].Op == vm.OP_VERIFY {
+		insts = insts[4:]
+	}
+	if len(insts) < 5 || insts[0].Op != vm.OP_TXSIGHASH || insts[len(insts)-1].Op != vm.OP_CHECKMULTISIG {
+		return nil, 0, errors.New("not a multisig program")
+	}
+
+	quorum, err := vm.AsInt64(insts[len(insts)-3].Data)
+	if err != nil {
+		return nil, 0, err
+	}
+	numPubKeys, err := vm.AsInt64(insts[len(insts)-2].Data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pubKeyInsts := insts[1 : len(insts)-3]
+	if numPubKeys != int64(len(pubKeyInsts)) || quorum < 1 || quorum > numPubKeys {
+		return nil, 0, errors.New("malformed multisig program")
+	}
+
+	var pubKeys []ed25519.PublicKey
+	for _, inst := range pubKeyInsts {
+		if len(inst.Data) != ed25519.PublicKeySize {
+			return nil, 0, errors.New("malformed multisig public key")
+		}
+		pubKeys = append(pubKeys, ed25519.PublicKey(inst.Data))
+	}
+	return pubKeys, int(quorum), nil
+}
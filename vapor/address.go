@@ -0,0 +1,109 @@
+package transaction
+
+import (
+	"encoding/hex"
+	"errors"
+
+	"github.com/bytom/vapor/consensus"
+	"github.com/bytom/vapor/consensus/segwit"
+
+	"github.com/vapor-sdk/util"
+)
+
+// VaporDecodeAddress decode a P2WPKH or P2WSH address of the network, the
+// returned error is a *util.DecodeError
+func VaporDecodeAddress(address, network string) (*util.Address, error) {
+	netParams, err := getNetParams(network)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAddress(address, netParams)
+}
+
+// VaporValidateAddress returns nil if address is a P2WPKH or P2WSH address of
+// the network, or a *util.DecodeError telling why it is not
+func VaporValidateAddress(address, network string) error {
+	_, err := VaporDecodeAddress(address, network)
+	return err
+}
+
+// VaporProgramAddress returns the address of a hex P2WPKH or P2WSH control
+// program on the network, the returned error is a *util.DecodeError
+func VaporProgramAddress(controlProgram, network string) (*util.Address, error) {
+	netParams, err := getNetParams(network)
+	if err != nil {
+		return nil, err
+	}
+
+	prog, err := hex.DecodeString(controlProgram)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindInvalidHex, chainName, err)
+	}
+	return programAddress(prog, netParams)
+}
+
+// decodeAddress returns the util.Address of an address of the network
+func decodeAddress(address string, netParams *consensus.Params) (*util.Address, error) {
+	prog, err := getControlProgramFromAddress(address, netParams)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindInvalidAddress, chainName, err)
+	}
+	return programAddress(prog, netParams)
+}
+
+// programAddress returns the util.Address of a control program on the network
+func programAddress(prog []byte, netParams *consensus.Params) (*util.Address, error) {
+	address := getAddressFromControlProgram(prog, false, netParams)
+	if address == "" {
+		return nil, util.NewDecodeError(util.ErrKindInvalidAddress, chainName, errors.New("script is not a P2WPKH or P2WSH program"))
+	}
+
+	witnessProgram, err := segwit.GetHashFromStandardProg(prog)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindInvalidAddress, chainName, err)
+	}
+
+	return &util.Address{
+		Address:        address,
+		Type:           getScriptType(prog),
+		WitnessProgram: hex.EncodeToString(witnessProgram),
+		ControlProgram: hex.EncodeToString(prog),
+	}, nil
+}
+
+// VaporToBytomAddress re-encode a vapor address of the network as the bytom
+// mainchain address of the same witness program, the way cross-chain outputs
+// are rendered. The returned error is a *util.DecodeError
+func VaporToBytomAddress(address, network string) (string, error) {
+	netParams, err := getNetParams(network)
+	if err != nil {
+		return "", err
+	}
+
+	decoded, err := decodeAddress(address, netParams)
+	if err != nil {
+		return "", err
+	}
+
+	prog, err := hex.DecodeString(decoded.ControlProgram)
+	if err != nil {
+		return "", util.NewDecodeError(util.ErrKindInvalidAddress, chainName, err)
+	}
+	return getAddressFromControlProgram(prog, true, netParams), nil
+}
+
+// BytomToVaporAddress re-encode a bytom mainchain address as the vapor address
+// of the same witness program on the network, the returned error is a
+// *util.DecodeError
+func BytomToVaporAddress(address, network string) (string, error) {
+	netParams, err := getNetParams(network)
+	if err != nil {
+		return "", err
+	}
+
+	prog, err := getControlProgramFromAddress(address, consensus.BytomMainNetParams(netParams))
+	if err != nil {
+		return "", util.NewDecodeError(util.ErrKindInvalidAddress, chainName, err)
+	}
+	return getAddressFromControlProgram(prog, false, netParams), nil
+}
@@ -0,0 +1,151 @@
+package transaction
+
+import (
+	"testing"
+
+	"github.com/vapor-sdk/util"
+)
+
+func TestVaporDecodeAddress(t *testing.T) {
+	multiSig, err := VaporMultiSig(testMultiSigKeys(t, "alice", "bob"), 1, "mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		address  string
+		network  string
+		want     *util.Address
+		wantKind util.ErrorKind
+	}{
+		{
+			address: "vp1q6e3pdmarzaee09eudctnlrmlz7nmvjup8wtqxd",
+			want: &util.Address{
+				Address:        "vp1q6e3pdmarzaee09eudctnlrmlz7nmvjup8wtqxd",
+				Type:           util.ScriptP2WPKH,
+				WitnessProgram: "d66216efa3177397973c6e173f8f7f17a7b64b81",
+				ControlProgram: "0014d66216efa3177397973c6e173f8f7f17a7b64b81",
+			},
+		},
+		{
+			address: "VP1Q6E3PDMARZAEE09EUDCTNLRMLZ7NMVJUP8WTQXD",
+			want: &util.Address{
+				Address:        "vp1q6e3pdmarzaee09eudctnlrmlz7nmvjup8wtqxd",
+				Type:           util.ScriptP2WPKH,
+				WitnessProgram: "d66216efa3177397973c6e173f8f7f17a7b64b81",
+				ControlProgram: "0014d66216efa3177397973c6e173f8f7f17a7b64b81",
+			},
+		},
+		{
+			address: multiSig.Address,
+			want: &util.Address{
+				Address:        multiSig.Address,
+				Type:           util.ScriptP2WSH,
+				WitnessProgram: multiSig.ControlProgram[4:],
+				ControlProgram: multiSig.ControlProgram,
+			},
+		},
+		{
+			address:  "vp1q6e3pdmarzaee09eudctnlrmlz7nmvjup8wtqxd",
+			network:  "testnet",
+			wantKind: util.ErrKindInvalidAddress,
+		},
+		{
+			address:  "vp1q6e3pdmarzaee09eudctnlrmlz7nmvjup8wtqxa",
+			wantKind: util.ErrKindInvalidAddress,
+		},
+		{
+			address:  "bm1q26kpwrrevhh2c8xrfy5vnaryu0ugc97c3j896t",
+			wantKind: util.ErrKindInvalidAddress,
+		},
+		{
+			address:  "",
+			wantKind: util.ErrKindInvalidAddress,
+		},
+		{
+			address:  "vp1q6e3pdmarzaee09eudctnlrmlz7nmvjup8wtqxd",
+			network:  "nonet",
+			wantKind: util.ErrKindUnsupportedNetwork,
+		},
+	}
+
+	for i, c := range cases {
+		got, err := VaporDecodeAddress(c.address, c.network)
+		if c.wantKind != "" {
+			if decodeErr, ok := err.(*util.DecodeError); !ok || decodeErr.Kind != c.wantKind {
+				t.Errorf("case #%d, got error %v, want %s", i, err, c.wantKind)
+			}
+			if VaporValidateAddress(c.address, c.network) == nil {
+				t.Errorf("case #%d, invalid address %s is validated", i, c.address)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case #%d: %v", i, err)
+		}
+		if *got != *c.want {
+			t.Errorf("case #%d, got address %#v, want %#v", i, got, c.want)
+		}
+
+		fromProgram, err := VaporProgramAddress(c.want.ControlProgram, c.network)
+		if err != nil {
+			t.Fatalf("case #%d: %v", i, err)
+		}
+		if *fromProgram != *c.want {
+			t.Errorf("case #%d, got address %#v of program, want %#v", i, fromProgram, c.want)
+		}
+	}
+
+	for i, program := range []string{"51", "6a", "0014", "zz"} {
+		if _, err := VaporProgramAddress(program, "mainnet"); err == nil {
+			t.Errorf("case #%d, got address of program %s", i, program)
+		}
+	}
+}
+
+func TestVaporConvertAddress(t *testing.T) {
+	cases := []struct {
+		vaporAddress string
+		bytomAddress string
+		network      string
+	}{
+		{
+			vaporAddress: "vp1q6e3pdmarzaee09eudctnlrmlz7nmvjup8wtqxd",
+			bytomAddress: "bm1q6e3pdmarzaee09eudctnlrmlz7nmvjupv0marm",
+			network:      "mainnet",
+		},
+		{
+			vaporAddress: "tp1q6e3pdmarzaee09eudctnlrmlz7nmvjup2vzdxe",
+			bytomAddress: "tm1q6e3pdmarzaee09eudctnlrmlz7nmvjupge6er2",
+			network:      "testnet",
+		},
+	}
+
+	for i, c := range cases {
+		bytomAddress, err := VaporToBytomAddress(c.vaporAddress, c.network)
+		if err != nil {
+			t.Fatalf("case #%d: %v", i, err)
+		}
+		if bytomAddress != c.bytomAddress {
+			t.Errorf("case #%d, got bytom address %s, want %s", i, bytomAddress, c.bytomAddress)
+		}
+
+		vaporAddress, err := BytomToVaporAddress(c.bytomAddress, c.network)
+		if err != nil {
+			t.Fatalf("case #%d: %v", i, err)
+		}
+		if vaporAddress != c.vaporAddress {
+			t.Errorf("case #%d, got vapor address %s, want %s", i, vaporAddress, c.vaporAddress)
+		}
+	}
+
+	if _, err := VaporToBytomAddress("bm1q6e3pdmarzaee09eudctnlrmlz7nmvjupv0marm", "mainnet"); err == nil {
+		t.Error("bytom address is converted as a vapor address")
+	}
+	if _, err := BytomToVaporAddress("vp1q6e3pdmarzaee09eudctnlrmlz7nmvjup8wtqxd", "mainnet"); err == nil {
+		t.Error("vapor address is converted as a bytom address")
+	}
+	if _, err := BytomToVaporAddress("tm1q6e3pdmarzaee09eudctnlrmlz7nmvjupge6er2", "mainnet"); err == nil {
+		t.Error("bytom testnet address is converted on mainnet")
+	}
+}
@@ -0,0 +1,112 @@
+package transaction
+
+import (
+	"encoding/hex"
+	"errors"
+
+	"github.com/bytom/vapor/protocol/bc/types"
+
+	"github.com/vapor-sdk/util"
+)
+
+var (
+	errNoBlockHeader       = errors.New("block serialization carries no header")
+	errNoBlockTransactions = errors.New("block serialization carries no transactions")
+)
+
+// VaporDecodeRawBlockWithOptions decode raw block into JSON, the returned error
+// is a *util.DecodeError
+func VaporDecodeRawBlockWithOptions(rawBlock string, mode util.BlockMode, opts util.DecodeOptions) ([]byte, error) {
+	block, err := VaporDecodeBlock(rawBlock, mode, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonBlock, err := util.MarshalBlock(block, opts)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindMarshal, chainName, err)
+	}
+	return jsonBlock, nil
+}
+
+// VaporDecodeBlock decode raw block into the annotated block, the returned
+// error is a *util.DecodeError
+func VaporDecodeBlock(rawBlock string, mode util.BlockMode, opts util.DecodeOptions) (*util.Block, error) {
+	if err := mode.Check(); err != nil {
+		return nil, util.NewDecodeError(util.ErrKindUnsupportedMode, chainName, err)
+	}
+
+	block, err := VaporParseRawBlock(rawBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	// the serialization flags tell which parts are present in the raw block
+	serflag, _ := hex.DecodeString(rawBlock[:2])
+	switch {
+	case mode != util.BlockModeTransactions && serflag[0] == types.SerBlockTransactions:
+		return nil, util.NewDecodeError(util.ErrKindMalformedBlock, chainName, errNoBlockHeader)
+	case mode != util.BlockModeHeader && serflag[0] == types.SerBlockHeader:
+		return nil, util.NewDecodeError(util.ErrKindMalformedBlock, chainName, errNoBlockTransactions)
+	}
+	return VaporAnnotateBlock(block, mode, opts)
+}
+
+// VaporParseRawBlock parse raw block into the underlying types.Block, the raw
+// block may be produced by MarshalText, MarshalTextForBlockHeader or
+// MarshalTextForTransactions. The returned error is a *util.DecodeError
+func VaporParseRawBlock(rawBlock string) (*types.Block, error) {
+	block := &types.Block{}
+	if err := block.UnmarshalText([]byte(rawBlock)); err != nil {
+		return nil, util.NewRawBlockError(chainName, rawBlock, err)
+	}
+	return block, nil
+}
+
+// VaporAnnotateBlock build the annotated block of a parsed types.Block, the
+// returned error is a *util.DecodeError
+func VaporAnnotateBlock(block *types.Block, mode util.BlockMode, opts util.DecodeOptions) (*util.Block, error) {
+	if err := mode.Check(); err != nil {
+		return nil, util.NewDecodeError(util.ErrKindUnsupportedMode, chainName, err)
+	}
+
+	if _, err := getNetParams(opts.Network); err != nil {
+		return nil, err
+	}
+
+	annotatedBlock := &util.Block{}
+	if mode != util.BlockModeTransactions {
+		annotatedBlock.Header = buildAnnotatedBlockHeader(&block.BlockHeader)
+	}
+	if mode == util.BlockModeHeader {
+		return annotatedBlock, nil
+	}
+
+	annotatedBlock.Transactions = []*util.Transaction{}
+	for _, rawTx := range block.Transactions {
+		tx, err := VaporAnnotateTx(rawTx, opts)
+		if err != nil {
+			return nil, err
+		}
+		annotatedBlock.Transactions = append(annotatedBlock.Transactions, tx)
+	}
+	return annotatedBlock, nil
+}
+
+// buildAnnotatedBlockHeader build the annotated block header.
+func buildAnnotatedBlockHeader(header *types.BlockHeader) *util.BlockHeader {
+	blockHash := header.Hash()
+	annotatedHeader := &util.BlockHeader{
+		Hash:                   blockHash.String(),
+		Version:                header.Version,
+		Height:                 header.Height,
+		PreviousBlockHash:      header.PreviousBlockHash.String(),
+		Timestamp:              header.Timestamp,
+		TransactionsMerkleRoot: header.TransactionsMerkleRoot.String(),
+		TransactionStatusHash:  header.TransactionStatusHash.String(),
+	}
+	for _, witness := range header.Witness {
+		annotatedHeader.Witness = append(annotatedHeader.Witness, hex.EncodeToString(witness))
+	}
+	return annotatedHeader
+}
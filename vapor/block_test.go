@@ -0,0 +1,119 @@
+package transaction
+
+import (
+	"testing"
+
+	"github.com/bytom/bytom/testutil"
+	"github.com/bytom/vapor/consensus"
+	"github.com/bytom/vapor/protocol/bc"
+	"github.com/bytom/vapor/protocol/bc/types"
+
+	"github.com/vapor-sdk/util"
+)
+
+func TestVaporDecodeBlock(t *testing.T) {
+	controlProgram := testutil.MustDecodeHexString("0014d66216efa3177397973c6e173f8f7f17a7b64b81")
+	block := &types.Block{
+		BlockHeader: types.BlockHeader{
+			Version:           1,
+			Height:            100,
+			PreviousBlockHash: bc.NewHash([32]byte{1}),
+			Timestamp:         1564186056000,
+			BlockWitness:      types.BlockWitness{Witness: [][]byte{[]byte{0xaa, 0xbb}}},
+		},
+		Transactions: []*types.Tx{
+			types.NewTx(types.TxData{
+				Version: 1,
+				Inputs:  []*types.TxInput{types.NewCoinbaseInput([]byte{0x01})},
+				Outputs: []*types.TxOutput{types.NewIntraChainOutput(*consensus.BTMAssetID, 0, controlProgram)},
+			}),
+		},
+	}
+
+	rawBlock, err := block.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rawHeader, err := block.MarshalTextForBlockHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rawTransactions, err := block.MarshalTextForTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blockHash := block.Hash()
+	cases := []struct {
+		rawBlock   []byte
+		mode       util.BlockMode
+		wantHeader bool
+		wantTxs    int
+		wantKind   util.ErrorKind
+	}{
+		{
+			rawBlock:   rawBlock,
+			mode:       util.BlockModeFull,
+			wantHeader: true,
+			wantTxs:    1,
+		},
+		{
+			rawBlock:   rawHeader,
+			mode:       util.BlockModeHeader,
+			wantHeader: true,
+		},
+		{
+			rawBlock: rawTransactions,
+			mode:     util.BlockModeTransactions,
+			wantTxs:  1,
+		},
+		{
+			rawBlock: rawTransactions,
+			mode:     util.BlockModeFull,
+			wantKind: util.ErrKindMalformedBlock,
+		},
+		{
+			rawBlock: rawHeader,
+			mode:     util.BlockModeTransactions,
+			wantKind: util.ErrKindMalformedBlock,
+		},
+		{
+			rawBlock: rawHeader,
+			mode:     util.BlockMode("raw"),
+			wantKind: util.ErrKindUnsupportedMode,
+		},
+		{
+			rawBlock: append([]byte("zz"), rawBlock...),
+			mode:     util.BlockModeFull,
+			wantKind: util.ErrKindInvalidHex,
+		},
+	}
+
+	for i, c := range cases {
+		gotBlock, err := VaporDecodeBlock(string(c.rawBlock), c.mode, util.DecodeOptions{})
+		if c.wantKind != "" {
+			if decodeErr, ok := err.(*util.DecodeError); !ok || decodeErr.Kind != c.wantKind {
+				t.Errorf("case #%d, got error %v, want kind %s", i, err, c.wantKind)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case #%d, %v", i, err)
+		}
+
+		if gotHeader := gotBlock.Header != nil; gotHeader != c.wantHeader {
+			t.Errorf("case #%d, got header %t, want %t", i, gotHeader, c.wantHeader)
+		}
+		if c.wantHeader {
+			header := gotBlock.Header
+			if header.Hash != blockHash.String() || header.Height != 100 || header.Timestamp != 1564186056000 || len(header.Witness) != 1 || header.Witness[0] != "aabb" || header.Nonce != nil {
+				t.Errorf("case #%d, got header %#v", i, header)
+			}
+		}
+		if len(gotBlock.Transactions) != c.wantTxs {
+			t.Errorf("case #%d, got %d transactions, want %d", i, len(gotBlock.Transactions), c.wantTxs)
+		}
+	}
+}
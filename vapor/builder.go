@@ -0,0 +1,227 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/bytom/vapor/consensus"
+	"github.com/bytom/vapor/consensus/segwit"
+	"github.com/bytom/vapor/crypto"
+	"github.com/bytom/vapor/math/checked"
+	"github.com/bytom/vapor/protocol/bc"
+	"github.com/bytom/vapor/protocol/bc/types"
+
+	"github.com/vapor-sdk/util"
+)
+
+// Action adds inputs or outputs to a TemplateBuilder, it is the offline
+// counterpart of txbuilder.Action.
+type Action interface {
+	Build(*TemplateBuilder) error
+	ActionType() string
+}
+
+// TemplateBuilder collects the inputs, outputs and signing instructions built
+// by the actions, it is the offline counterpart of txbuilder.TemplateBuilder.
+type TemplateBuilder struct {
+	netParams           *consensus.Params
+	inputs              []*types.TxInput
+	outputs             []*types.TxOutput
+	signingInstructions []*util.SigningInstruction
+	minHeight           uint64
+}
+
+// AddInput add an input and the instruction to sign it
+func (b *TemplateBuilder) AddInput(in *types.TxInput, sigInst *util.SigningInstruction) error {
+	if in.Amount() > math.MaxInt64 {
+		return fmt.Errorf("amount %d exceeds the maximum of %d", in.Amount(), int64(math.MaxInt64))
+	}
+	b.inputs = append(b.inputs, in)
+	b.signingInstructions = append(b.signingInstructions, sigInst)
+	return nil
+}
+
+// AddOutput add an output
+func (b *TemplateBuilder) AddOutput(o *types.TxOutput) error {
+	if amount := o.AssetAmount().Amount; amount > math.MaxInt64 {
+		return fmt.Errorf("amount %d exceeds the maximum of %d", amount, int64(math.MaxInt64))
+	}
+	b.outputs = append(b.outputs, o)
+	return nil
+}
+
+// RestrictMinHeight raises the first block height at which the transaction
+// can be included
+func (b *TemplateBuilder) RestrictMinHeight(height uint64) {
+	if height > b.minHeight {
+		b.minHeight = height
+	}
+}
+
+// Build builds the unsigned template. The time range must not expire before
+// the minimum height, and every asset except BTM must balance, the BTM
+// surplus is the fee.
+func (b *TemplateBuilder) Build(timeRange uint64) (*util.Template, error) {
+	if timeRange > math.MaxInt64 {
+		return nil, fmt.Errorf("time range %d exceeds the maximum of %d", timeRange, int64(math.MaxInt64))
+	}
+	if timeRange != 0 && timeRange < b.minHeight {
+		return nil, fmt.Errorf("time range %d expires before height %d at which all inputs are spendable", timeRange, b.minHeight)
+	}
+	if len(b.inputs) == 0 || len(b.outputs) == 0 {
+		return nil, errors.New("transaction needs at least one input and one output")
+	}
+
+	tx := types.NewTx(types.TxData{Version: 1, TimeRange: timeRange, Inputs: b.inputs, Outputs: b.outputs})
+	fee, err := checkBalance(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	rawTransaction, err := tx.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	tpl := &util.Template{RawTransaction: string(rawTransaction), Fee: fee}
+	for i, sigInst := range b.signingInstructions {
+		signData := tx.SigHash(uint32(i))
+		sigInst.Position = uint32(i)
+		sigInst.SignData = signData.String()
+		tpl.SigningInstructions = append(tpl.SigningInstructions, sigInst)
+	}
+	return tpl, nil
+}
+
+// VaporBuildTx build an unsigned template from the JSON form of a
+// util.BuildRequest and return it as JSON, the returned error is a
+// *util.DecodeError
+func VaporBuildTx(jsonRequest []byte) ([]byte, error) {
+	req := &util.BuildRequest{}
+	if err := json.Unmarshal(jsonRequest, req); err != nil {
+		return nil, invalidTxError(err)
+	}
+
+	var actions []Action
+	for i, data := range req.Actions {
+		action, err := DecodeAction(data)
+		if err != nil {
+			return nil, invalidTxError(fmt.Errorf("action %d: %v", i, err))
+		}
+		actions = append(actions, action)
+	}
+
+	tpl, err := VaporBuild(actions, req.TimeRange, req.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonTpl, err := json.Marshal(tpl)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindMarshal, chainName, err)
+	}
+	return jsonTpl, nil
+}
+
+// VaporBuild build an unsigned template from actions without any node, the
+// addresses are decoded for the given network. timeRange is the last block
+// height at which the transaction is valid, 0 means no limit. The returned
+// error is a *util.DecodeError
+func VaporBuild(actions []Action, timeRange uint64, network string) (*util.Template, error) {
+	netParams, err := getNetParams(network)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := &TemplateBuilder{netParams: netParams}
+	for i, action := range actions {
+		if err := action.Build(builder); err != nil {
+			return nil, invalidTxError(fmt.Errorf("action %d %s: %v", i, action.ActionType(), err))
+		}
+	}
+
+	tpl, err := builder.Build(timeRange)
+	if err != nil {
+		return nil, invalidTxError(err)
+	}
+	return tpl, nil
+}
+
+// checkBalance returns the fee of tx, the BTM surplus, and requires every
+// other asset to balance.
+func checkBalance(tx *types.Tx) (uint64, error) {
+	var assetIDs []bc.AssetID
+	balances := make(map[bc.AssetID]int64)
+	addBalance := func(assetID bc.AssetID, amount int64) error {
+		balance, ok := balances[assetID]
+		if !ok {
+			assetIDs = append(assetIDs, assetID)
+		}
+		if balances[assetID], ok = checked.AddInt64(balance, amount); !ok {
+			return fmt.Errorf("amounts of asset %x overflow", assetID.Bytes())
+		}
+		return nil
+	}
+
+	for _, in := range tx.Inputs {
+		if err := addBalance(in.AssetID(), int64(in.Amount())); err != nil {
+			return 0, err
+		}
+	}
+	for _, out := range tx.Outputs {
+		assetAmount := out.AssetAmount()
+		if err := addBalance(*assetAmount.AssetId, -int64(assetAmount.Amount)); err != nil {
+			return 0, err
+		}
+	}
+
+	for _, assetID := range assetIDs {
+		if balance := balances[assetID]; assetID == *consensus.BTMAssetID && balance < 0 {
+			return 0, fmt.Errorf("BTM outputs exceed inputs by %d", -balance)
+		} else if assetID != *consensus.BTMAssetID && balance != 0 {
+			return 0, fmt.Errorf("asset %x is unbalanced by %d", assetID.Bytes(), balance)
+		}
+	}
+	return uint64(balances[*consensus.BTMAssetID]), nil
+}
+
+// buildSigningInstruction returns the instruction to sign a spend of utxo.
+// Without keys the witness is left to the caller, a P2WPKH script is signed
+// with a raw transaction signature followed by the derived public key, and a
+// P2WSH script with the multisig witness of buildMultiSigInstruction.
+func buildSigningInstruction(utxo *util.UTXO, controlProgram []byte) (*util.SigningInstruction, error) {
+	sigInst := &util.SigningInstruction{WitnessComponents: []*util.WitnessComponent{}}
+	if len(utxo.Keys) == 0 {
+		return sigInst, nil
+	}
+	if segwit.IsP2WSHScript(controlProgram) {
+		return buildMultiSigInstruction(utxo, controlProgram)
+	}
+	if !segwit.IsP2WPKHScript(controlProgram) || len(utxo.Keys) != 1 {
+		return nil, errors.New("signing keys are only supported for a P2WPKH script with one key or a P2WSH multisig script")
+	}
+
+	xpub, path, err := util.DecodeKeyID(utxo.Keys[0])
+	if err != nil {
+		return nil, err
+	}
+
+	pubKey := xpub.Derive(path).PublicKey()
+	pubHash, err := segwit.GetHashFromStandardProg(controlProgram)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(crypto.Ripemd160(pubKey), pubHash) {
+		return nil, errors.New("signing key does not control the script")
+	}
+
+	sigInst.WitnessComponents = append(sigInst.WitnessComponents,
+		&util.WitnessComponent{Type: util.WitnessRawTxSignature, Quorum: 1, Keys: utxo.Keys},
+		&util.WitnessComponent{Type: util.WitnessData, Value: hex.EncodeToString(pubKey)},
+	)
+	return sigInst, nil
+}
@@ -0,0 +1,148 @@
+package transaction
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/bytom/bytom/crypto/ed25519/chainkd"
+	"github.com/bytom/vapor/common"
+	"github.com/bytom/vapor/consensus"
+	"github.com/bytom/vapor/crypto"
+	"github.com/bytom/vapor/protocol/vm/vmutil"
+
+	"github.com/vapor-sdk/util"
+)
+
+// testP2WPKHKey returns a signing key and the P2WPKH program it controls
+func testP2WPKHKey(t *testing.T, seed string) (util.KeyID, []byte) {
+	xpub := chainkd.RootXPrv([]byte(seed)).XPub()
+	path := [][]byte{{0x2c, 0, 0, 0}, {0x99, 0, 0, 0}}
+	controlProgram, err := vmutil.P2WPKHProgram(crypto.Ripemd160(xpub.Derive(path).PublicKey()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return util.KeyID{XPub: xpub.String(), DerivationPath: []string{"2c000000", "99000000"}}, controlProgram
+}
+
+func TestVaporBuildTx(t *testing.T) {
+	key, controlProgram := testP2WPKHKey(t, "alice")
+	_, receiverProgram := testP2WPKHKey(t, "bob")
+	mainchainAddress, err := common.NewAddressWitnessPubKeyHash(receiverProgram[2:], consensus.BytomMainNetParams(&consensus.MainNetParams))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	btm := consensus.BTMAssetID.String()
+	vote := "9742a39a0bcfb5b7ac8f56f1894fbb694b53ebf58f9a032c36cc22d57a06e49e94ff7199063fb7a78190624fa3530f611404b56fc9af91dcaf4639614512cb64"
+	spendUTXO := util.UTXO{
+		SourceID:       "0100000000000000000000000000000000000000000000000000000000000000",
+		AssetID:        btm,
+		Amount:         300000000,
+		ControlProgram: hex.EncodeToString(controlProgram),
+		Keys:           []util.KeyID{key},
+	}
+	vetoUTXO := util.UTXO{
+		SourceID:       "0200000000000000000000000000000000000000000000000000000000000000",
+		AssetID:        btm,
+		Amount:         100000000,
+		ControlProgram: hex.EncodeToString(controlProgram),
+		Vote:           vote,
+		ValidHeight:    3456000,
+		Keys:           []util.KeyID{key},
+	}
+	spendJSON, err := json.Marshal(spendUTXO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vetoJSON, err := json.Marshal(vetoUTXO)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := fmt.Sprintf(`{"actions": [
+		{"type": "spend_utxo", "utxo": %s},
+		{"type": "veto_input", "utxo": %s},
+		{"type": "vote_output", "asset": "%s", "amount": 200000000, "script": "%x", "vote": "%s"},
+		{"type": "cross_chain_out", "asset": "%s", "amount": 150000000, "address": "%s"},
+		{"type": "retire", "asset": "%s", "amount": 40000000}
+	]}`, spendJSON, vetoJSON, btm, controlProgram, vote, btm, mainchainAddress.EncodeAddress(), btm)
+
+	jsonTpl, err := VaporBuildTx([]byte(request))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tpl := &util.Template{}
+	if err := json.Unmarshal(jsonTpl, tpl); err != nil {
+		t.Fatal(err)
+	}
+
+	if tpl.Fee != 10000000 {
+		t.Errorf("got fee %d, want 10000000", tpl.Fee)
+	}
+
+	tx, err := VaporDecodeTx(tpl.RawTransaction, util.DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantInputTypes := []string{"spend", "veto"}
+	for i, in := range tx.Inputs {
+		if in.Type != wantInputTypes[i] || in.SignData != tpl.SigningInstructions[i].SignData || len(tpl.SigningInstructions[i].WitnessComponents) != 2 {
+			t.Errorf("input #%d, got %#v with signing instruction %#v", i, in, tpl.SigningInstructions[i])
+		}
+	}
+
+	wantOutputTypes := []string{"vote", "cross_chain_out", "retire"}
+	for i, out := range tx.Outputs {
+		if out.Type != wantOutputTypes[i] {
+			t.Errorf("output #%d, got type %s, want %s", i, out.Type, wantOutputTypes[i])
+		}
+	}
+	if tx.Outputs[0].Vote != vote || tx.Outputs[1].Address != mainchainAddress.EncodeAddress() {
+		t.Errorf("got outputs %#v", tx.Outputs)
+	}
+}
+
+func TestVaporBuildInvalid(t *testing.T) {
+	_, controlProgram := testP2WPKHKey(t, "alice")
+	btm := consensus.BTMAssetID.String()
+	utxo := util.UTXO{
+		SourceID:       "0100000000000000000000000000000000000000000000000000000000000000",
+		AssetID:        btm,
+		Amount:         100,
+		ControlProgram: hex.EncodeToString(controlProgram),
+		ValidHeight:    1000,
+	}
+	amount := util.AssetAmount{AssetID: btm, Amount: 100}
+	cases := []struct {
+		actions   []Action
+		timeRange uint64
+	}{
+		{
+			actions: []Action{&VetoInputAction{UTXO: utxo}, &ControlProgramAction{AssetAmount: amount, ControlProgram: hex.EncodeToString(controlProgram)}},
+		},
+		{
+			actions: []Action{&SpendUTXOAction{UTXO: utxo}, &VoteOutputAction{AssetAmount: amount, ControlProgram: hex.EncodeToString(controlProgram)}},
+		},
+		{
+			actions: []Action{&SpendUTXOAction{UTXO: utxo}, &CrossChainOutAction{AssetAmount: amount, Address: "vp1q6e3pdmarzaee09eudctnlrmlz7nmvjup8wtqxd"}},
+		},
+		{
+			actions:   []Action{&SpendUTXOAction{UTXO: utxo}, &RetireAction{AssetAmount: amount}},
+			timeRange: 999,
+		},
+		{
+			actions: []Action{&SpendUTXOAction{UTXO: utxo}, &RetireAction{AssetAmount: util.AssetAmount{AssetID: btm, Amount: 101}}},
+		},
+	}
+
+	for i, c := range cases {
+		_, err := VaporBuild(c.actions, c.timeRange, "mainnet")
+		if decodeErr, ok := err.(*util.DecodeError); !ok || decodeErr.Kind != util.ErrKindInvalidTx {
+			t.Errorf("case #%d, got error %v, want invalid transaction", i, err)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package transaction
+
+import (
+	"testing"
+
+	"github.com/bytom/bytom/testutil"
+	"github.com/bytom/vapor/consensus"
+	"github.com/bytom/vapor/protocol/bc"
+	"github.com/bytom/vapor/protocol/bc/types"
+
+	"github.com/vapor-sdk/util"
+)
+
+func TestVaporEncodeRawTx(t *testing.T) {
+	controlProgram := testutil.MustDecodeHexString("0014d66216efa3177397973c6e173f8f7f17a7b64b81")
+	issuanceProgram := testutil.MustDecodeHexString("ae2054a71277cc162eb3eb21b5bd9fe54402829a53b294deaed91692a2cd8a081f9c5151ad")
+	vote := testutil.MustDecodeHexString("9742a39a0bcfb5b7ac8f56f1894fbb694b53ebf58f9a032c36cc22d57a06e49e94ff7199063fb7a78190624fa3530f611404b56fc9af91dcaf4639614512cb64")
+	assetID := bc.NewAssetID([32]byte{1})
+
+	crossChainInput := types.NewCrossChainInput([][]byte{{1}}, bc.NewHash([32]byte{1}), assetID, 10000, 1, 1, []byte(`{"name":"ETH"}`), issuanceProgram)
+	crossChainInput.TypedInput.(*types.CrossChainInput).ControlProgram = controlProgram
+	crossChainTx := types.NewTx(types.TxData{
+		Version: 1,
+		Inputs:  []*types.TxInput{crossChainInput},
+		Outputs: []*types.TxOutput{types.NewIntraChainOutput(assetID, 10000, controlProgram)},
+	})
+	crossChainRawTx, err := crossChainTx.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vetoTx := types.NewTx(types.TxData{
+		Version:   1,
+		TimeRange: 100,
+		Inputs: []*types.TxInput{
+			types.NewVetoInput([][]byte{{2}}, bc.NewHash([32]byte{2}), *consensus.BTMAssetID, 300000000, 0, controlProgram, vote),
+		},
+		Outputs: []*types.TxOutput{
+			types.NewCrossChainOutput(*consensus.BTMAssetID, 100000000, controlProgram),
+			types.NewIntraChainOutput(*consensus.BTMAssetID, 100000000, []byte{0x6a, 0x04, 'b', 'u', 'r', 'n'}),
+		},
+	})
+	vetoRawTx, err := vetoTx.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	coinbaseTx := types.NewTx(types.TxData{
+		Version: 1,
+		Inputs:  []*types.TxInput{types.NewCoinbaseInput([]byte("arbitrary"))},
+		Outputs: []*types.TxOutput{types.NewVoteOutput(*consensus.BTMAssetID, 100000000, controlProgram, vote)},
+	})
+	coinbaseRawTx, err := coinbaseTx.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []string{
+		`07010001015f015d13c41cc617304ba0866fa59f07d7bb2bcab60c43e5cc79bb75a4dd97471cdcbaffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff80ade20400011600144b6995dc11354d44c6e382c19d6b92bdbbd3aea1010002013e003cffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffc096b102011600149682e64b2114f7c2581ab1ba0c67315d06aaea8200013e003cffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffc096b10201160014da26416fa79947ec6a569e0493dbffec1a3f223400`,
+		string(crossChainRawTx),
+		string(vetoRawTx),
+		string(coinbaseRawTx),
+	}
+
+	for i, rawTransaction := range cases {
+		wantTx, err := VaporParseRawTx(rawTransaction)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the JSON of the decoder round trips with numbers and string amounts
+		for _, opts := range []util.DecodeOptions{{}, {StringAmounts: true}} {
+			jsonTx, err := VaporDecodeRawTxWithOptions(rawTransaction, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gotRawTransaction, err := VaporEncodeRawTx(jsonTx)
+			if err != nil {
+				t.Fatalf("case #%d, string amounts %t, encode error: %v", i, opts.StringAmounts, err)
+			}
+
+			gotTx, err := VaporParseRawTx(gotRawTransaction)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if gotRawTransaction != rawTransaction || gotTx.ID != wantTx.ID {
+				t.Errorf("case #%d, string amounts %t, got raw transaction %s, want %s", i, opts.StringAmounts, gotRawTransaction, rawTransaction)
+			}
+		}
+	}
+}
+
+func TestVaporEncodeTxRetireComment(t *testing.T) {
+	tx := &util.Transaction{
+		Version: 1,
+		Inputs: []util.AnnotatedInput{
+			{Type: "spend", SourceID: "0100000000000000000000000000000000000000000000000000000000000000", AssetID: consensus.BTMAssetID.String(), Amount: 100, ControlProgram: "0014d66216efa3177397973c6e173f8f7f17a7b64b81"},
+		},
+		Outputs: []util.AnnotatedOutput{
+			{Type: "retire", AssetID: consensus.BTMAssetID.String(), Amount: 100, RetireComment: "6275726e"},
+		},
+	}
+
+	rawTx, err := VaporEncodeTx(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gotTx, err := VaporAnnotateTx(rawTx, util.DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out := gotTx.Outputs[0]; out.Type != "retire" || out.ControlProgram != "6a046275726e" || out.RetireComment != "6275726e" {
+		t.Errorf("got output %#v, want retire output with comment 6275726e", out)
+	}
+}
+
+func TestVaporEncodeTxInvalid(t *testing.T) {
+	btm := consensus.BTMAssetID.String()
+	sourceID := "0100000000000000000000000000000000000000000000000000000000000000"
+	spend := util.AnnotatedInput{Type: "spend", SourceID: sourceID, AssetID: btm, Amount: 100, ControlProgram: "0014d66216efa3177397973c6e173f8f7f17a7b64b81"}
+	control := util.AnnotatedOutput{Type: "control", AssetID: btm, Amount: 100, ControlProgram: "0014d66216efa3177397973c6e173f8f7f17a7b64b81"}
+	cases := []struct {
+		input  util.AnnotatedInput
+		output util.AnnotatedOutput
+	}{
+		{
+			input:  util.AnnotatedInput{Type: "issue", AssetID: btm},
+			output: control,
+		},
+		{
+			input:  util.AnnotatedInput{Type: "spend", SourceID: sourceID, AssetID: "ff"},
+			output: control,
+		},
+		{
+			input:  util.AnnotatedInput{Type: "veto", SourceID: sourceID, AssetID: btm},
+			output: control,
+		},
+		{
+			input:  util.AnnotatedInput{Type: "spend", SourceID: sourceID, AssetID: btm, WitnessArguments: []string{"xyz"}},
+			output: control,
+		},
+		{
+			input:  spend,
+			output: util.AnnotatedOutput{Type: "vote", AssetID: btm, ControlProgram: "51"},
+		},
+		{
+			input:  spend,
+			output: util.AnnotatedOutput{Type: "control", AssetID: btm, Amount: 1 << 63, ControlProgram: "51"},
+		},
+		{
+			input:  spend,
+			output: util.AnnotatedOutput{Type: "retire", AssetID: btm, ControlProgram: "51"},
+		},
+		{
+			input:  spend,
+			output: util.AnnotatedOutput{Type: "retire", AssetID: btm, ControlProgram: "6a0101", RetireComment: "02"},
+		},
+	}
+
+	for i, c := range cases {
+		tx := &util.Transaction{
+			Version: 1,
+			Inputs:  []util.AnnotatedInput{c.input},
+			Outputs: []util.AnnotatedOutput{c.output},
+		}
+		_, err := VaporEncodeTx(tx)
+		if decodeErr, ok := err.(*util.DecodeError); !ok || decodeErr.Kind != util.ErrKindInvalidTx {
+			t.Errorf("case #%d, got error %v, want invalid transaction", i, err)
+		}
+	}
+
+	if _, err := VaporEncodeRawTx([]byte(`{"inputs": 1}`)); err == nil {
+		t.Error("malformed JSON encoded")
+	}
+}
@@ -0,0 +1,63 @@
+package transaction
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bytom/vapor/crypto"
+	"github.com/bytom/vapor/protocol/vm/vmutil"
+
+	"github.com/vapor-sdk/util"
+)
+
+// VaporDeriveKeys derive the keys of the JSON form of a util.DeriveRequest and
+// return the []util.DerivedKey as JSON, the returned error is a
+// *util.DecodeError
+func VaporDeriveKeys(jsonRequest []byte) ([]byte, error) {
+	req := &util.DeriveRequest{}
+	if err := json.Unmarshal(jsonRequest, req); err != nil {
+		return nil, util.NewDecodeError(util.ErrKindInvalidKey, chainName, err)
+	}
+
+	keys, err := VaporDerive(req.Key, req.Paths, req.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonKeys, err := json.Marshal(keys)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindMarshal, chainName, err)
+	}
+	return jsonKeys, nil
+}
+
+// VaporDerive derive the key, P2WPKH program and address of each path from an
+// extended private or public key, see util.DeriveKey. The returned error is a
+// *util.DecodeError
+func VaporDerive(key util.Key, paths []string, network string) ([]*util.DerivedKey, error) {
+	netParams, err := getNetParams(network)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []*util.DerivedKey{}
+	for _, path := range paths {
+		derived, err := util.DeriveKey(key, path)
+		if err != nil {
+			return nil, util.NewDecodeError(util.ErrKindInvalidKey, chainName, fmt.Errorf("path %s: %v", path, err))
+		}
+
+		pubKey, _ := hex.DecodeString(derived.PublicKey)
+		pubHash := crypto.Ripemd160(pubKey)
+		controlProgram, err := vmutil.P2WPKHProgram(pubHash)
+		if err != nil {
+			return nil, util.NewDecodeError(util.ErrKindInvalidKey, chainName, err)
+		}
+
+		derived.ControlProgram = hex.EncodeToString(controlProgram)
+		derived.Address = buildP2PKHAddress(pubHash, netParams)
+		keys = append(keys, derived)
+	}
+	return keys, nil
+}
@@ -0,0 +1,350 @@
+package transaction
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/bytom/vapor/consensus"
+	"github.com/bytom/vapor/consensus/segwit"
+	"github.com/bytom/vapor/protocol/bc"
+	"github.com/bytom/vapor/protocol/bc/types"
+	"github.com/bytom/vapor/protocol/vm"
+	"github.com/bytom/vapor/protocol/vm/vmutil"
+
+	"github.com/vapor-sdk/util"
+)
+
+// the magnetic contract pays the seller feeNumerator/feeDenominator of the
+// exchanged amount, the rest is the fee of the matcher
+const (
+	feeNumerator   = 999
+	feeDenominator = 1000
+)
+
+// MatchOrdersAction settles the crossing pairs among a set of order UTXOs.
+// The best priced orders of each side of a trade pair are matched first, and
+// every order is used at most once, the change of a partial fill is locked in
+// a new order at the same ratio. The fee kept by the contracts is paid to the
+// fee address or program, except BTM which is left as the transaction fee.
+type MatchOrdersAction struct {
+	Orders     []util.UTXO `json:"orders"`
+	FeeAddress string      `json:"fee_address,omitempty"`
+	FeeProgram string      `json:"fee_program,omitempty"`
+}
+
+// Build add the order inputs and the settlement outputs
+func (a *MatchOrdersAction) Build(b *TemplateBuilder) error {
+	var orders []*openOrder
+	for i := range a.Orders {
+		order, err := decodeOpenOrder(&a.Orders[i])
+		if err != nil {
+			return fmt.Errorf("order %d: %v", i, err)
+		}
+		orders = append(orders, order)
+	}
+
+	pairs := findCrossingOrders(orders)
+	if len(pairs) == 0 {
+		return errors.New("no crossing orders")
+	}
+
+	fees := &assetBalances{}
+	for _, pair := range pairs {
+		if err := settleOrders(b, pair[0], pair[1], fees); err != nil {
+			return err
+		}
+	}
+
+	for _, assetID := range fees.assetIDs {
+		amount := fees.amounts[assetID]
+		if amount == 0 || assetID == *consensus.BTMAssetID {
+			continue
+		}
+
+		feeProgram, err := getReceiverProgram(a.FeeAddress, a.FeeProgram, b.netParams)
+		if err != nil {
+			return fmt.Errorf("fee of asset %x: %v", assetID.Bytes(), err)
+		}
+		if err := b.AddOutput(types.NewIntraChainOutput(assetID, amount, feeProgram)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ActionType return the type of the action
+func (a *MatchOrdersAction) ActionType() string {
+	return "match_orders"
+}
+
+// openOrder is an order UTXO with its decoded contract arguments
+type openOrder struct {
+	sourceID       bc.Hash
+	sourcePosition uint64
+	assetID        bc.AssetID
+	amount         uint64
+	controlProgram []byte
+	args           *vmutil.MagneticContractArgs
+}
+
+func decodeOpenOrder(utxo *util.UTXO) (*openOrder, error) {
+	sourceID, err := decodeHashField("source_id", utxo.SourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	assetID, amount, err := decodeAssetAmount(util.AssetAmount{AssetID: utxo.AssetID, Amount: utxo.Amount})
+	if err != nil {
+		return nil, err
+	}
+
+	controlProgram, err := decodeHexField("script", utxo.ControlProgram)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := segwit.DecodeP2WMCProgram(controlProgram)
+	if err != nil {
+		return nil, err
+	}
+	if args.RatioNumerator <= 0 || args.RatioDenominator <= 0 {
+		return nil, fmt.Errorf("invalid ratio %d/%d", args.RatioNumerator, args.RatioDenominator)
+	}
+	if args.RequestedAsset == assetID {
+		return nil, errors.New("order requests the offered asset")
+	}
+
+	return &openOrder{
+		sourceID:       sourceID,
+		sourcePosition: utxo.SourcePosition,
+		assetID:        assetID,
+		amount:         amount,
+		controlProgram: controlProgram,
+		args:           args,
+	}, nil
+}
+
+// cheaper reports whether o asks less of the requested asset for each unit of
+// the offered asset than other
+func (o *openOrder) cheaper(other *openOrder) bool {
+	x := new(big.Int).Mul(big.NewInt(o.args.RatioNumerator), big.NewInt(other.args.RatioDenominator))
+	y := new(big.Int).Mul(big.NewInt(other.args.RatioNumerator), big.NewInt(o.args.RatioDenominator))
+	return x.Cmp(y) < 0
+}
+
+// crosses reports whether o and other, which trade the same assets in
+// opposite directions, both get at least the price they ask
+func (o *openOrder) crosses(other *openOrder) bool {
+	x := new(big.Int).Mul(big.NewInt(o.args.RatioNumerator), big.NewInt(other.args.RatioNumerator))
+	y := new(big.Int).Mul(big.NewInt(o.args.RatioDenominator), big.NewInt(other.args.RatioDenominator))
+	return x.Cmp(y) <= 0
+}
+
+// findCrossingOrders pairs the orders of each trade pair best price first,
+// while the prices of the pair cross. The trade pairs are matched in the order
+// they are first seen.
+func findCrossingOrders(orders []*openOrder) [][2]*openOrder {
+	type tradePair struct{ from, to bc.AssetID }
+	var tradePairs []tradePair
+	books := make(map[tradePair][]*openOrder)
+	for _, order := range orders {
+		pair := tradePair{from: order.assetID, to: order.args.RequestedAsset}
+		if _, ok := books[pair]; !ok {
+			tradePairs = append(tradePairs, pair)
+		}
+		books[pair] = append(books[pair], order)
+	}
+
+	for _, book := range books {
+		sort.SliceStable(book, func(i, j int) bool { return book[i].cheaper(book[j]) })
+	}
+
+	var matches [][2]*openOrder
+	matched := make(map[tradePair]bool)
+	for _, pair := range tradePairs {
+		oppositePair := tradePair{from: pair.to, to: pair.from}
+		if matched[oppositePair] {
+			continue
+		}
+		matched[pair] = true
+
+		book, opposite := books[pair], books[oppositePair]
+		for i := 0; i < len(book) && i < len(opposite) && book[i].crosses(opposite[i]); i++ {
+			matches = append(matches, [2]*openOrder{book[i], opposite[i]})
+		}
+	}
+	return matches
+}
+
+// orderFill is the clause spending an order. A partial fill credits the
+// seller with exchange of the requested asset and releases part of the
+// order, a full fill releases all of it.
+type orderFill struct {
+	full     bool
+	exchange uint64
+	released uint64
+	received uint64
+}
+
+// fullFill returns the full trade clause of o
+func fullFill(o *openOrder) (*orderFill, error) {
+	requested, err := mulFraction(o.amount, o.args.RatioNumerator, o.args.RatioDenominator)
+	if err != nil {
+		return nil, err
+	}
+
+	received, err := mulFraction(requested, feeNumerator, feeDenominator)
+	if err != nil {
+		return nil, err
+	}
+	return &orderFill{full: true, released: o.amount, received: received}, nil
+}
+
+// partialFill returns the partial trade clause of o crediting exchange of the
+// requested asset
+func partialFill(o *openOrder, exchange uint64) (*orderFill, error) {
+	released, err := mulFraction(exchange, o.args.RatioDenominator, o.args.RatioNumerator)
+	if err != nil {
+		return nil, err
+	}
+	if released == 0 || released >= o.amount {
+		return nil, fmt.Errorf("partial fill releases %d of %d", released, o.amount)
+	}
+
+	received, err := mulFraction(exchange, feeNumerator, feeDenominator)
+	if err != nil {
+		return nil, err
+	}
+	return &orderFill{exchange: exchange, released: released, received: received}, nil
+}
+
+// fillOrders returns the clauses settling a against b. The order which is
+// smaller at the price of the other one is filled fully, and the other one
+// is credited with all of its amount.
+func fillOrders(a, b *openOrder) (*orderFill, *orderFill, error) {
+	releasedB, err := mulFraction(a.amount, b.args.RatioDenominator, b.args.RatioNumerator)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	releasedA, err := mulFraction(b.amount, a.args.RatioDenominator, a.args.RatioNumerator)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var fillA, fillB *orderFill
+	switch {
+	case releasedB < b.amount:
+		if fillA, err = fullFill(a); err != nil {
+			return nil, nil, err
+		}
+		fillB, err = partialFill(b, a.amount)
+
+	case releasedA < a.amount:
+		if fillB, err = fullFill(b); err != nil {
+			return nil, nil, err
+		}
+		fillA, err = partialFill(a, b.amount)
+
+	default:
+		if fillA, err = fullFill(a); err != nil {
+			return nil, nil, err
+		}
+		fillB, err = fullFill(b)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if fillA.received == 0 || fillB.received == 0 {
+		return nil, nil, errors.New("a seller receives nothing")
+	}
+	if fillA.released < fillB.received || fillB.released < fillA.received {
+		return nil, nil, errors.New("released amounts do not cover the sellers")
+	}
+	return fillA, fillB, nil
+}
+
+// settleOrders add the inputs and outputs settling a crossing pair, and the
+// fees left by the contracts to fees
+func settleOrders(b *TemplateBuilder, a, o *openOrder, fees *assetBalances) error {
+	fillA, fillO, err := fillOrders(a, o)
+	if err != nil {
+		return fmt.Errorf("orders %x and %x can not be settled: %v", a.sourceID.Bytes(), o.sourceID.Bytes(), err)
+	}
+
+	if err := addOrderFill(b, a, fillA); err != nil {
+		return err
+	}
+	if err := addOrderFill(b, o, fillO); err != nil {
+		return err
+	}
+
+	fees.add(a.assetID, fillA.released-fillO.received)
+	fees.add(o.assetID, fillO.released-fillA.received)
+	return nil
+}
+
+// addOrderFill add the input spending o with fill, the output paying the
+// seller and, for a partial fill, the output locking the change in the order
+func addOrderFill(b *TemplateBuilder, o *openOrder, fill *orderFill) error {
+	position := len(b.outputs)
+	witness := []*util.WitnessComponent{
+		{Type: util.WitnessData, Value: hex.EncodeToString(vm.Int64Bytes(int64(position)))},
+		{Type: util.WitnessData, Value: hex.EncodeToString(vm.Int64Bytes(fullTradeClause))},
+	}
+	if !fill.full {
+		witness = []*util.WitnessComponent{
+			{Type: util.WitnessData, Value: hex.EncodeToString(vm.Int64Bytes(int64(fill.exchange)))},
+			{Type: util.WitnessData, Value: hex.EncodeToString(vm.Int64Bytes(int64(position)))},
+			{Type: util.WitnessData, Value: hex.EncodeToString(vm.Int64Bytes(partialTradeClause))},
+		}
+	}
+
+	in := types.NewSpendInput(nil, o.sourceID, o.assetID, o.amount, o.sourcePosition, o.controlProgram)
+	if err := b.AddInput(in, &util.SigningInstruction{WitnessComponents: witness}); err != nil {
+		return err
+	}
+	if err := b.AddOutput(types.NewIntraChainOutput(o.args.RequestedAsset, fill.received, o.args.SellerProgram)); err != nil {
+		return err
+	}
+	if fill.full {
+		return nil
+	}
+
+	changeProgram, err := vmutil.P2WMCProgram(*o.args)
+	if err != nil {
+		return err
+	}
+	return b.AddOutput(types.NewIntraChainOutput(o.assetID, o.amount-fill.released, changeProgram))
+}
+
+// mulFraction returns x*numerator/denominator rounded down like
+// OP_MULFRACTION
+func mulFraction(x uint64, numerator, denominator int64) (uint64, error) {
+	res := new(big.Int).SetUint64(x)
+	res.Mul(res, big.NewInt(numerator)).Quo(res, big.NewInt(denominator))
+	if !res.IsInt64() {
+		return 0, fmt.Errorf("%d*%d/%d is out of range", x, numerator, denominator)
+	}
+	return res.Uint64(), nil
+}
+
+// assetBalances sums amounts by asset in the order the assets are first seen
+type assetBalances struct {
+	assetIDs []bc.AssetID
+	amounts  map[bc.AssetID]uint64
+}
+
+func (ab *assetBalances) add(assetID bc.AssetID, amount uint64) {
+	if ab.amounts == nil {
+		ab.amounts = make(map[bc.AssetID]uint64)
+	}
+	if _, ok := ab.amounts[assetID]; !ok {
+		ab.assetIDs = append(ab.assetIDs, assetID)
+	}
+	ab.amounts[assetID] += amount
+}
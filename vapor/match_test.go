@@ -0,0 +1,224 @@
+package transaction
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/bytom/vapor/consensus"
+	"github.com/bytom/vapor/protocol/bc"
+	"github.com/bytom/vapor/protocol/vm/vmutil"
+
+	"github.com/vapor-sdk/util"
+)
+
+// testOrderUTXO returns an order of seed offering amount of asset for
+// requestedAsset at ratio numerator/denominator
+func testOrderUTXO(t *testing.T, seed string, asset, requestedAsset bc.AssetID, amount uint64, numerator, denominator int64) util.UTXO {
+	_, sellerProgram := testP2WPKHKey(t, seed)
+	pubKey := make([]byte, 32)
+	copy(pubKey, seed)
+	controlProgram, err := vmutil.P2WMCProgram(vmutil.MagneticContractArgs{
+		RequestedAsset:   requestedAsset,
+		RatioNumerator:   numerator,
+		RatioDenominator: denominator,
+		SellerProgram:    sellerProgram,
+		SellerKey:        pubKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sourceID := bc.NewHash([32]byte{byte(len(seed)), byte(amount), byte(numerator), byte(denominator)})
+	return util.UTXO{
+		SourceID:       sourceID.String(),
+		AssetID:        asset.String(),
+		Amount:         amount,
+		ControlProgram: hex.EncodeToString(controlProgram),
+	}
+}
+
+func TestVaporMatchOrders(t *testing.T) {
+	btm := *consensus.BTMAssetID
+	eth := bc.NewAssetID([32]byte{1})
+	_, feeProgram := testP2WPKHKey(t, "matcher")
+	_, aliceProgram := testP2WPKHKey(t, "alice")
+	_, bobProgram := testP2WPKHKey(t, "bob")
+
+	type output struct {
+		asset   bc.AssetID
+		amount  uint64
+		program string
+	}
+
+	cases := []struct {
+		desc        string
+		orders      []util.UTXO
+		wantOutputs []output
+		wantClauses []string
+		wantFee     uint64
+	}{
+		{
+			desc: "full fill against partial fill",
+			orders: []util.UTXO{
+				testOrderUTXO(t, "alice", btm, eth, 100000000, 3, 2),
+				testOrderUTXO(t, "bob", eth, btm, 300000000, 1, 2),
+			},
+			wantOutputs: []output{
+				{asset: eth, amount: 149850000, program: hex.EncodeToString(aliceProgram)},
+				{asset: btm, amount: 99900000, program: hex.EncodeToString(bobProgram)},
+				{asset: eth, amount: 100000000},
+				{asset: eth, amount: 50150000, program: hex.EncodeToString(feeProgram)},
+			},
+			wantClauses: []string{util.OrderFullTrade, util.OrderPartialTrade},
+			wantFee:     100000,
+		},
+		{
+			desc: "partial fill against full fill",
+			orders: []util.UTXO{
+				testOrderUTXO(t, "alice", btm, eth, 500000000, 3, 2),
+				testOrderUTXO(t, "bob", eth, btm, 300000000, 1, 2),
+			},
+			wantOutputs: []output{
+				{asset: eth, amount: 299700000, program: hex.EncodeToString(aliceProgram)},
+				{asset: btm, amount: 300000000},
+				{asset: btm, amount: 149850000, program: hex.EncodeToString(bobProgram)},
+				{asset: eth, amount: 300000, program: hex.EncodeToString(feeProgram)},
+			},
+			wantClauses: []string{util.OrderPartialTrade, util.OrderFullTrade},
+			wantFee:     50150000,
+		},
+		{
+			desc: "full fill against full fill",
+			orders: []util.UTXO{
+				testOrderUTXO(t, "alice", btm, eth, 200000000, 1, 1),
+				testOrderUTXO(t, "bob", eth, btm, 200000000, 1, 1),
+			},
+			wantOutputs: []output{
+				{asset: eth, amount: 199800000, program: hex.EncodeToString(aliceProgram)},
+				{asset: btm, amount: 199800000, program: hex.EncodeToString(bobProgram)},
+				{asset: eth, amount: 200000, program: hex.EncodeToString(feeProgram)},
+			},
+			wantClauses: []string{util.OrderFullTrade, util.OrderFullTrade},
+			wantFee:     200000,
+		},
+		{
+			desc: "best prices first",
+			orders: []util.UTXO{
+				testOrderUTXO(t, "carol", btm, eth, 100000000, 2, 1),
+				testOrderUTXO(t, "alice", btm, eth, 100000000, 1, 1),
+				testOrderUTXO(t, "bob", eth, btm, 100000000, 1, 1),
+			},
+			wantOutputs: []output{
+				{asset: eth, amount: 99900000, program: hex.EncodeToString(aliceProgram)},
+				{asset: btm, amount: 99900000, program: hex.EncodeToString(bobProgram)},
+				{asset: eth, amount: 100000, program: hex.EncodeToString(feeProgram)},
+			},
+			wantClauses: []string{util.OrderFullTrade, util.OrderFullTrade},
+			wantFee:     100000,
+		},
+	}
+
+	for _, c := range cases {
+		// the gas of the matcher is paid by an OP_TRUE output
+		tpl, err := VaporBuild([]Action{
+			&MatchOrdersAction{Orders: c.orders, FeeProgram: hex.EncodeToString(feeProgram)},
+			&SpendUTXOAction{UTXO: util.UTXO{
+				SourceID:       "0900000000000000000000000000000000000000000000000000000000000000",
+				AssetID:        btm.String(),
+				Amount:         100000000,
+				ControlProgram: "51",
+			}},
+		}, 0, "mainnet")
+		if err != nil {
+			t.Fatalf("%s: %v", c.desc, err)
+		}
+		if _, err := VaporSignTemplate(tpl, util.XPrvSignFunc()); err != nil {
+			t.Fatalf("%s: %v", c.desc, err)
+		}
+
+		tx, err := VaporDecodeTx(tpl.RawTransaction, util.DecodeOptions{})
+		if err != nil {
+			t.Fatalf("%s: %v", c.desc, err)
+		}
+		if tx.Fee != c.wantFee+100000000 || len(tx.Outputs) != len(c.wantOutputs) || len(tx.Inputs) != len(c.wantClauses)+1 {
+			t.Fatalf("%s: got fee %d, %d inputs and %d outputs", c.desc, tx.Fee, len(tx.Inputs), len(tx.Outputs))
+		}
+		for i, want := range c.wantOutputs {
+			got := tx.Outputs[i]
+			if got.AssetID != want.asset.String() || got.Amount != want.amount || (want.program != "" && got.ControlProgram != want.program) || (want.program == "" && got.Order == nil) {
+				t.Errorf("%s: got output #%d %#v, want %#v", c.desc, i, got, want)
+			}
+		}
+		for i, want := range c.wantClauses {
+			if got := tx.Inputs[i]; got.Order == nil || got.Order.Clause != want {
+				t.Errorf("%s: got input #%d %#v, want clause %s", c.desc, i, got.Order, want)
+			}
+		}
+
+		rawTx, err := VaporParseRawTx(tpl.RawTransaction)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := VaporValidateTx(rawTx, util.ValidateOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Valid {
+			t.Errorf("%s: settlement is not valid: %s", c.desc, result.Error)
+		}
+	}
+}
+
+func TestVaporMatchOrdersError(t *testing.T) {
+	btm := *consensus.BTMAssetID
+	eth := bc.NewAssetID([32]byte{1})
+	_, feeProgram := testP2WPKHKey(t, "matcher")
+	_, aliceProgram := testP2WPKHKey(t, "alice")
+
+	cases := []struct {
+		desc   string
+		action *MatchOrdersAction
+	}{
+		{
+			desc: "prices do not cross",
+			action: &MatchOrdersAction{Orders: []util.UTXO{
+				testOrderUTXO(t, "alice", btm, eth, 100000000, 2, 1),
+				testOrderUTXO(t, "bob", eth, btm, 100000000, 1, 1),
+			}, FeeProgram: hex.EncodeToString(feeProgram)},
+		},
+		{
+			desc: "same side orders",
+			action: &MatchOrdersAction{Orders: []util.UTXO{
+				testOrderUTXO(t, "alice", btm, eth, 100000000, 1, 1),
+				testOrderUTXO(t, "bob", btm, eth, 100000000, 1, 1),
+			}, FeeProgram: hex.EncodeToString(feeProgram)},
+		},
+		{
+			desc: "no fee program",
+			action: &MatchOrdersAction{Orders: []util.UTXO{
+				testOrderUTXO(t, "alice", btm, eth, 100000000, 1, 1),
+				testOrderUTXO(t, "bob", eth, btm, 100000000, 1, 1),
+			}},
+		},
+		{
+			desc: "not an order",
+			action: &MatchOrdersAction{Orders: []util.UTXO{
+				testOrderUTXO(t, "alice", btm, eth, 100000000, 1, 1),
+				{SourceID: "0900000000000000000000000000000000000000000000000000000000000000", AssetID: eth.String(), Amount: 100000000, ControlProgram: hex.EncodeToString(aliceProgram)},
+			}, FeeProgram: hex.EncodeToString(feeProgram)},
+		},
+		{
+			desc: "seller receives nothing",
+			action: &MatchOrdersAction{Orders: []util.UTXO{
+				testOrderUTXO(t, "alice", btm, eth, 1, 1, 1),
+				testOrderUTXO(t, "bob", eth, btm, 1, 1, 1),
+			}, FeeProgram: hex.EncodeToString(feeProgram)},
+		},
+	}
+
+	for _, c := range cases {
+		if _, err := VaporBuild([]Action{c.action}, 0, "mainnet"); err == nil {
+			t.Errorf("%s: got no error", c.desc)
+		}
+	}
+}
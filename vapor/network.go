@@ -0,0 +1,25 @@
+package transaction
+
+import (
+	"fmt"
+
+	"github.com/bytom/vapor/consensus"
+
+	"github.com/vapor-sdk/util"
+)
+
+const defaultNetwork = "mainnet"
+
+// getNetParams returns the consensus params of the vapor network, the network
+// must be one of the chain IDs in consensus.NetParams
+func getNetParams(network string) (*consensus.Params, error) {
+	if network == "" {
+		network = defaultNetwork
+	}
+
+	params, ok := consensus.NetParams[network]
+	if !ok {
+		return nil, util.NewDecodeError(util.ErrKindUnsupportedNetwork, chainName, fmt.Errorf("unsupported network %q", network))
+	}
+	return &params, nil
+}
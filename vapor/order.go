@@ -0,0 +1,219 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/bytom/vapor/consensus"
+	"github.com/bytom/vapor/consensus/segwit"
+	"github.com/bytom/vapor/protocol/bc/types"
+	"github.com/bytom/vapor/protocol/vm"
+	"github.com/bytom/vapor/protocol/vm/vmutil"
+
+	"github.com/vapor-sdk/util"
+)
+
+// clause selectors of vmutil.P2MCProgram, the last witness argument of an
+// input spending an order
+const (
+	partialTradeClause = 0
+	fullTradeClause    = 1
+	cancelClause       = 2
+)
+
+// PlaceOrderAction locks an amount of the offered asset in a magnetic contract
+// order asking RatioNumerator of RequestedAsset for every RatioDenominator of
+// it. A trade pays the seller address or program, and SellerKey can cancel the
+// order.
+type PlaceOrderAction struct {
+	util.AssetAmount
+	RequestedAsset   string     `json:"requested_asset"`
+	RatioNumerator   int64      `json:"ratio_numerator"`
+	RatioDenominator int64      `json:"ratio_denominator"`
+	SellerAddress    string     `json:"seller_address,omitempty"`
+	SellerProgram    string     `json:"seller_program,omitempty"`
+	SellerKey        util.KeyID `json:"seller_key"`
+}
+
+// Build add the order output
+func (a *PlaceOrderAction) Build(b *TemplateBuilder) error {
+	assetID, amount, err := decodeAssetAmount(a.AssetAmount)
+	if err != nil {
+		return err
+	}
+
+	requestedAsset, err := decodeAssetIDField(a.RequestedAsset)
+	if err != nil {
+		return err
+	}
+	if requestedAsset == assetID {
+		return errors.New("order requests the offered asset")
+	}
+
+	sellerProgram, err := getReceiverProgram(a.SellerAddress, a.SellerProgram, b.netParams)
+	if err != nil {
+		return err
+	}
+
+	xpub, path, err := util.DecodeKeyID(a.SellerKey)
+	if err != nil {
+		return err
+	}
+
+	controlProgram, err := orderProgram(vmutil.MagneticContractArgs{
+		RequestedAsset:   requestedAsset,
+		RatioNumerator:   a.RatioNumerator,
+		RatioDenominator: a.RatioDenominator,
+		SellerProgram:    sellerProgram,
+		SellerKey:        xpub.Derive(path).PublicKey(),
+	})
+	if err != nil {
+		return err
+	}
+	return b.AddOutput(types.NewIntraChainOutput(assetID, amount, controlProgram))
+}
+
+// ActionType return the type of the action
+func (a *PlaceOrderAction) ActionType() string {
+	return "place_order"
+}
+
+// CancelOrderAction spends an order back to its seller program with the
+// cancel clause, signed by the seller key in the keys of the UTXO. Fee is
+// kept from the refund, it is only allowed for a BTM order.
+type CancelOrderAction struct {
+	UTXO util.UTXO `json:"utxo"`
+	Fee  uint64    `json:"fee,omitempty"`
+}
+
+// Build add the order input and the refund output
+func (a *CancelOrderAction) Build(b *TemplateBuilder) error {
+	sourceID, err := decodeHashField("source_id", a.UTXO.SourceID)
+	if err != nil {
+		return err
+	}
+
+	assetID, amount, err := decodeAssetAmount(util.AssetAmount{AssetID: a.UTXO.AssetID, Amount: a.UTXO.Amount})
+	if err != nil {
+		return err
+	}
+	if a.Fee > 0 && assetID != *consensus.BTMAssetID {
+		return errors.New("fee can only be kept from a BTM order")
+	}
+	if a.Fee >= amount {
+		return fmt.Errorf("fee %d leaves no refund of %d", a.Fee, amount)
+	}
+
+	controlProgram, err := decodeHexField("script", a.UTXO.ControlProgram)
+	if err != nil {
+		return err
+	}
+
+	args, err := segwit.DecodeP2WMCProgram(controlProgram)
+	if err != nil {
+		return err
+	}
+
+	sigInst, err := buildCancelOrderInstruction(&a.UTXO, args)
+	if err != nil {
+		return err
+	}
+
+	b.RestrictMinHeight(a.UTXO.ValidHeight)
+	if err := b.AddInput(types.NewSpendInput(nil, sourceID, assetID, amount, a.UTXO.SourcePosition, controlProgram), sigInst); err != nil {
+		return err
+	}
+	return b.AddOutput(types.NewIntraChainOutput(assetID, amount-a.Fee, args.SellerProgram))
+}
+
+// ActionType return the type of the action
+func (a *CancelOrderAction) ActionType() string {
+	return "cancel_order"
+}
+
+// orderProgram returns the P2WMC program of an order, the seller program must
+// be a segwit program as the contract requires
+func orderProgram(args vmutil.MagneticContractArgs) ([]byte, error) {
+	if args.RatioNumerator <= 0 || args.RatioDenominator <= 0 {
+		return nil, fmt.Errorf("invalid ratio %d/%d", args.RatioNumerator, args.RatioDenominator)
+	}
+
+	prog, err := vmutil.P2WMCProgram(args)
+	if err != nil {
+		return nil, err
+	}
+	if !segwit.IsP2WMCScript(prog) {
+		return nil, errors.New("seller program is not a segwit program")
+	}
+	return prog, nil
+}
+
+// buildCancelOrderInstruction returns the instruction to cancel an order, the
+// seller signature followed by the unused position and the cancel clause
+// selector.
+func buildCancelOrderInstruction(utxo *util.UTXO, args *vmutil.MagneticContractArgs) (*util.SigningInstruction, error) {
+	if len(utxo.Keys) != 1 {
+		return nil, errors.New("cancel order needs the seller key")
+	}
+
+	xpub, path, err := util.DecodeKeyID(utxo.Keys[0])
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(xpub.Derive(path).PublicKey(), args.SellerKey) {
+		return nil, errors.New("signing key is not the seller key of the order")
+	}
+
+	return &util.SigningInstruction{WitnessComponents: []*util.WitnessComponent{
+		{Type: util.WitnessRawTxSignature, Quorum: 1, Keys: utxo.Keys},
+		{Type: util.WitnessData, Value: hex.EncodeToString(vm.Int64Bytes(0))},
+		{Type: util.WitnessData, Value: hex.EncodeToString(vm.Int64Bytes(cancelClause))},
+	}}, nil
+}
+
+// getOrder returns the order of a P2WMC program, or nil if prog is not a
+// magnetic contract
+func getOrder(prog []byte, netParams *consensus.Params) *util.Order {
+	if !segwit.IsP2WMCScript(prog) {
+		return nil
+	}
+
+	args, err := segwit.DecodeP2WMCProgram(prog)
+	if err != nil {
+		return nil
+	}
+
+	return &util.Order{
+		RequestedAsset:   args.RequestedAsset.String(),
+		RatioNumerator:   args.RatioNumerator,
+		RatioDenominator: args.RatioDenominator,
+		SellerProgram:    hex.EncodeToString(args.SellerProgram),
+		SellerAddress:    getAddressFromControlProgram(args.SellerProgram, false, netParams),
+		SellerKey:        hex.EncodeToString(args.SellerKey),
+	}
+}
+
+// getOrderClause returns the clause of the magnetic contract selected by the
+// witness arguments of an input, or an empty string if it is unknown
+func getOrderClause(arguments [][]byte) string {
+	if len(arguments) == 0 {
+		return ""
+	}
+
+	selector, err := vm.AsInt64(arguments[len(arguments)-1])
+	if err != nil {
+		return ""
+	}
+
+	switch selector {
+	case partialTradeClause:
+		return util.OrderPartialTrade
+	case fullTradeClause:
+		return util.OrderFullTrade
+	case cancelClause:
+		return util.OrderCancel
+	}
+	return ""
+}
@@ -0,0 +1,203 @@
+package transaction
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/bytom/bytom/crypto/ed25519/chainkd"
+	"github.com/bytom/bytom/testutil"
+	"github.com/bytom/vapor/consensus"
+	"github.com/bytom/vapor/protocol/bc"
+	"github.com/bytom/vapor/protocol/bc/types"
+	"github.com/bytom/vapor/protocol/vm"
+	"github.com/bytom/vapor/protocol/vm/vmutil"
+
+	"github.com/vapor-sdk/util"
+)
+
+func TestVaporDecodeOrder(t *testing.T) {
+	sellerProgram := testutil.MustDecodeHexString("0014d66216efa3177397973c6e173f8f7f17a7b64b81")
+	sellerKey := testutil.MustDecodeHexString("2fb851c6ed665fcd9ebc259da1461a1e284ac3b27f5e86c84164aa5186482226")
+	requestedAsset := bc.NewAssetID([32]byte{1})
+	orderProgram, err := vmutil.P2WMCProgram(vmutil.MagneticContractArgs{
+		RequestedAsset:   requestedAsset,
+		RatioNumerator:   3,
+		RatioDenominator: 2,
+		SellerProgram:    sellerProgram,
+		SellerKey:        sellerKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantOrder := util.Order{
+		RequestedAsset:   requestedAsset.String(),
+		RatioNumerator:   3,
+		RatioDenominator: 2,
+		SellerProgram:    hex.EncodeToString(sellerProgram),
+		SellerAddress:    "vp1q6e3pdmarzaee09eudctnlrmlz7nmvjup8wtqxd",
+		SellerKey:        hex.EncodeToString(sellerKey),
+	}
+
+	cases := []struct {
+		arguments  [][]byte
+		wantClause string
+	}{
+		{arguments: [][]byte{vm.Int64Bytes(100), vm.Int64Bytes(0), vm.Int64Bytes(0)}, wantClause: util.OrderPartialTrade},
+		{arguments: [][]byte{vm.Int64Bytes(0), vm.Int64Bytes(1)}, wantClause: util.OrderFullTrade},
+		{arguments: [][]byte{make([]byte, 64), vm.Int64Bytes(0), vm.Int64Bytes(2)}, wantClause: util.OrderCancel},
+		{arguments: [][]byte{vm.Int64Bytes(3)}},
+		{},
+	}
+
+	for i, c := range cases {
+		tx := types.NewTx(types.TxData{
+			Version: 1,
+			Inputs: []*types.TxInput{
+				types.NewSpendInput(c.arguments, bc.NewHash([32]byte{1}), *consensus.BTMAssetID, 200, 0, orderProgram),
+			},
+			Outputs: []*types.TxOutput{
+				types.NewIntraChainOutput(*consensus.BTMAssetID, 100, orderProgram),
+				types.NewIntraChainOutput(*consensus.BTMAssetID, 100, sellerProgram),
+			},
+		})
+
+		rawTx, err := tx.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		gotTx, err := VaporDecodeTx(string(rawTx), util.DecodeOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wantInputOrder := wantOrder
+		wantInputOrder.Clause = c.wantClause
+		if in := gotTx.Inputs[0]; in.Order == nil || *in.Order != wantInputOrder || in.ScriptType != util.ScriptP2WMC {
+			t.Errorf("case #%d, got input order %#v, want %#v", i, in.Order, wantInputOrder)
+		}
+		if out := gotTx.Outputs[0]; out.Order == nil || *out.Order != wantOrder {
+			t.Errorf("case #%d, got output order %#v, want %#v", i, out.Order, wantOrder)
+		}
+		if gotTx.Outputs[1].Order != nil {
+			t.Errorf("case #%d, got order %#v of a P2WPKH output", i, gotTx.Outputs[1].Order)
+		}
+	}
+}
+
+func TestVaporPlaceAndCancelOrder(t *testing.T) {
+	key, controlProgram := testP2WPKHKey(t, "alice")
+	bobKey, _ := testP2WPKHKey(t, "bob")
+	btm := consensus.BTMAssetID.String()
+	requestedAssetID := bc.NewAssetID([32]byte{1})
+	requestedAsset := requestedAssetID.String()
+
+	placeTpl, err := VaporBuild([]Action{
+		&SpendUTXOAction{UTXO: util.UTXO{
+			SourceID:       "0100000000000000000000000000000000000000000000000000000000000000",
+			AssetID:        btm,
+			Amount:         200000000,
+			ControlProgram: hex.EncodeToString(controlProgram),
+			Keys:           []util.KeyID{key},
+		}},
+		&PlaceOrderAction{
+			AssetAmount:      util.AssetAmount{AssetID: btm, Amount: 100000000},
+			RequestedAsset:   requestedAsset,
+			RatioNumerator:   3,
+			RatioDenominator: 2,
+			SellerProgram:    hex.EncodeToString(controlProgram),
+			SellerKey:        key,
+		},
+	}, 0, "mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	placeTx, err := VaporDecodeTx(placeTpl.RawTransaction, util.DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := placeTx.Outputs[0]
+	if order.Order == nil || order.Order.RequestedAsset != requestedAsset || order.Order.RatioNumerator != 3 || order.Order.RatioDenominator != 2 || order.Order.SellerProgram != hex.EncodeToString(controlProgram) {
+		t.Fatalf("got order output %#v", order)
+	}
+
+	orderUTXO := util.UTXO{
+		SourceID:       placeTx.Outputs[0].OutputID,
+		AssetID:        btm,
+		Amount:         order.Amount,
+		ControlProgram: order.ControlProgram,
+		Keys:           []util.KeyID{key},
+	}
+	cancelTpl, err := VaporBuild([]Action{&CancelOrderAction{UTXO: orderUTXO, Fee: 1000000}}, 0, "mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VaporSignTemplate(cancelTpl, util.XPrvSignFunc(chainkd.RootXPrv([]byte("alice")))); err != nil {
+		t.Fatal(err)
+	}
+
+	cancelTx, err := VaporDecodeTx(cancelTpl.RawTransaction, util.DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in := cancelTx.Inputs[0]; in.Order == nil || in.Order.Clause != util.OrderCancel {
+		t.Errorf("got cancel input %#v", in)
+	}
+	if out := cancelTx.Outputs[0]; out.Amount != 99000000 || out.ControlProgram != hex.EncodeToString(controlProgram) {
+		t.Errorf("got refund output %#v", out)
+	}
+
+	rawTx, err := VaporParseRawTx(cancelTpl.RawTransaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := VaporValidateTx(rawTx, util.ValidateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || result.Inputs[0].Status != util.ValidationPass {
+		t.Errorf("cancel transaction is not valid: %s", result.Error)
+	}
+
+	cases := []struct {
+		desc   string
+		action Action
+	}{
+		{
+			desc:   "cancel signed by another key",
+			action: &CancelOrderAction{UTXO: util.UTXO{SourceID: orderUTXO.SourceID, AssetID: btm, Amount: orderUTXO.Amount, ControlProgram: orderUTXO.ControlProgram, Keys: []util.KeyID{bobKey}}},
+		},
+		{
+			desc:   "cancel of a P2WPKH output",
+			action: &CancelOrderAction{UTXO: util.UTXO{SourceID: orderUTXO.SourceID, AssetID: btm, Amount: orderUTXO.Amount, ControlProgram: hex.EncodeToString(controlProgram), Keys: []util.KeyID{key}}},
+		},
+		{
+			desc:   "fee above the refund",
+			action: &CancelOrderAction{UTXO: orderUTXO, Fee: orderUTXO.Amount},
+		},
+		{
+			desc:   "fee of a non-BTM order",
+			action: &CancelOrderAction{UTXO: util.UTXO{SourceID: orderUTXO.SourceID, AssetID: requestedAsset, Amount: orderUTXO.Amount, ControlProgram: orderUTXO.ControlProgram, Keys: []util.KeyID{key}}, Fee: 1},
+		},
+		{
+			desc:   "order requesting the offered asset",
+			action: &PlaceOrderAction{AssetAmount: util.AssetAmount{AssetID: btm, Amount: 1}, RequestedAsset: btm, RatioNumerator: 1, RatioDenominator: 1, SellerProgram: hex.EncodeToString(controlProgram), SellerKey: key},
+		},
+		{
+			desc:   "order with a zero ratio",
+			action: &PlaceOrderAction{AssetAmount: util.AssetAmount{AssetID: btm, Amount: 1}, RequestedAsset: requestedAsset, RatioNumerator: 0, RatioDenominator: 1, SellerProgram: hex.EncodeToString(controlProgram), SellerKey: key},
+		},
+		{
+			desc:   "order paying a non-segwit program",
+			action: &PlaceOrderAction{AssetAmount: util.AssetAmount{AssetID: btm, Amount: 1}, RequestedAsset: requestedAsset, RatioNumerator: 1, RatioDenominator: 1, SellerProgram: "5151", SellerKey: key},
+		},
+	}
+
+	for _, c := range cases {
+		if _, err := VaporBuild([]Action{c.action}, 0, "mainnet"); err == nil {
+			t.Errorf("%s: got no error", c.desc)
+		}
+	}
+}
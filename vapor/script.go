@@ -0,0 +1,93 @@
+package transaction
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"github.com/bytom/vapor/consensus/segwit"
+	"github.com/bytom/vapor/crypto"
+	"github.com/bytom/vapor/protocol/vm"
+	"github.com/bytom/vapor/protocol/vm/vmutil"
+
+	"github.com/vapor-sdk/util"
+)
+
+// VaporAssembleScript assemble the program text of the JSON form of a
+// util.AssembleRequest and return a util.Script as JSON, the returned error is
+// a *util.DecodeError
+func VaporAssembleScript(jsonRequest []byte) ([]byte, error) {
+	req := &util.AssembleRequest{}
+	if err := json.Unmarshal(jsonRequest, req); err != nil {
+		return nil, util.NewDecodeError(util.ErrKindInvalidScript, chainName, err)
+	}
+
+	script, err := VaporAssemble(req.Source, req.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonScript, err := json.Marshal(script)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindMarshal, chainName, err)
+	}
+	return jsonScript, nil
+}
+
+// VaporAssemble assemble the program text source with vm.Assemble, and return
+// the program with its witness script hash, P2WSH program and address. The
+// returned error is a *util.DecodeError
+func VaporAssemble(source, network string) (*util.Script, error) {
+	netParams, err := getNetParams(network)
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := vm.Assemble(source)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindInvalidScript, chainName, err)
+	}
+	if len(program) == 0 {
+		return nil, util.NewDecodeError(util.ErrKindInvalidScript, chainName, errors.New("empty program"))
+	}
+
+	scriptHash := crypto.Sha256(program)
+	controlProgram, err := vmutil.P2WSHProgram(scriptHash)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindInvalidScript, chainName, err)
+	}
+
+	return &util.Script{
+		Program:        hex.EncodeToString(program),
+		ScriptHash:     hex.EncodeToString(scriptHash),
+		ControlProgram: hex.EncodeToString(controlProgram),
+		Address:        buildP2SHAddress(scriptHash, netParams),
+	}, nil
+}
+
+// getScriptType returns the util script type of a program
+func getScriptType(prog []byte) string {
+	switch {
+	case vmutil.IsUnspendable(prog):
+		return util.ScriptRetire
+	case segwit.IsP2WPKHScript(prog):
+		return util.ScriptP2WPKH
+	case segwit.IsP2WSHScript(prog):
+		return util.ScriptP2WSH
+	case segwit.IsP2WMCScript(prog):
+		return util.ScriptP2WMC
+	case util.IsMultiSigProgram(prog):
+		return util.ScriptMultiSig
+	}
+	return util.ScriptNonStandard
+}
+
+// disassembleProgram returns the assembly of a program, or an empty string if
+// the program can not be parsed
+func disassembleProgram(prog []byte) string {
+	asm, err := vm.Disassemble(prog)
+	if err != nil {
+		return ""
+	}
+	return asm
+}
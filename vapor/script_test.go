@@ -0,0 +1,128 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bytom/vapor/crypto"
+	"github.com/bytom/vapor/crypto/ed25519"
+	"github.com/bytom/vapor/protocol/bc"
+	"github.com/bytom/vapor/protocol/vm/vmutil"
+
+	"github.com/vapor-sdk/util"
+)
+
+func TestVaporScriptType(t *testing.T) {
+	pubKey := ed25519.PublicKey(bytes.Repeat([]byte{1}, ed25519.PublicKeySize))
+	multiSigProgram, err := vmutil.P2SPMultiSigProgram([]ed25519.PublicKey{pubKey}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2wmcProgram, err := vmutil.P2WMCProgram(vmutil.MagneticContractArgs{
+		RequestedAsset:   bc.AssetID{V0: 1},
+		RatioNumerator:   1,
+		RatioDenominator: 2,
+		SellerProgram:    []byte{0x51},
+		SellerKey:        pubKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		program  string
+		wantType string
+		wantAsm  string
+	}{
+		{
+			program:  "0014c3d320e1dc4fe787e9f13c1464e3ea5aae96a58f",
+			wantType: util.ScriptP2WPKH,
+			wantAsm:  "FALSE 0xc3d320e1dc4fe787e9f13c1464e3ea5aae96a58f",
+		},
+		{
+			program:  "0020" + strings.Repeat("ab", 32),
+			wantType: util.ScriptP2WSH,
+			wantAsm:  "FALSE 0x" + strings.Repeat("ab", 32),
+		},
+		{
+			program:  hex.EncodeToString(multiSigProgram),
+			wantType: util.ScriptMultiSig,
+			wantAsm:  "TXSIGHASH 0x" + strings.Repeat("01", 32) + " 0x01 0x01 CHECKMULTISIG",
+		},
+		{
+			program:  hex.EncodeToString(p2wmcProgram),
+			wantType: util.ScriptP2WMC,
+			wantAsm:  "FALSE 0x0000000000000001000000000000000000000000000000000000000000000000 0x01 0x02 0x51 0x" + strings.Repeat("01", 32),
+		},
+		{
+			program:  "6a07636f6d6d656e74",
+			wantType: util.ScriptRetire,
+			wantAsm:  "FAIL 0x636f6d6d656e74",
+		},
+		{
+			program:  "51",
+			wantType: util.ScriptNonStandard,
+			wantAsm:  "0x01",
+		},
+		{
+			program:  "4c",
+			wantType: util.ScriptNonStandard,
+		},
+	}
+
+	for i, c := range cases {
+		program, err := hex.DecodeString(c.program)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := getScriptType(program); got != c.wantType {
+			t.Errorf("case #%d, got script type %s, want %s", i, got, c.wantType)
+		}
+		if got := disassembleProgram(program); got != c.wantAsm {
+			t.Errorf("case #%d, got script asm %q, want %q", i, got, c.wantAsm)
+		}
+	}
+}
+
+func TestVaporAssemble(t *testing.T) {
+	script, err := VaporAssemble("TRUE", "mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if script.Program != "51" || script.ScriptHash != hex.EncodeToString(crypto.Sha256([]byte{0x51})) || script.ControlProgram != "0020"+script.ScriptHash || !strings.HasPrefix(script.Address, "vp1q") {
+		t.Errorf("got script %#v", script)
+	}
+
+	jsonScript, err := VaporAssembleScript([]byte(`{"source": "TRUE", "network": "testnet"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testnetScript := &util.Script{}
+	if err := json.Unmarshal(jsonScript, testnetScript); err != nil {
+		t.Fatal(err)
+	}
+	if testnetScript.ControlProgram != script.ControlProgram || !strings.HasPrefix(testnetScript.Address, "tp1q") {
+		t.Errorf("got testnet script %#v", testnetScript)
+	}
+
+	cases := []struct {
+		source   string
+		network  string
+		wantKind util.ErrorKind
+	}{
+		{source: "NOTANOPCODE", wantKind: util.ErrKindInvalidScript},
+		{source: "", wantKind: util.ErrKindInvalidScript},
+		{source: "TRUE", network: "nonet", wantKind: util.ErrKindUnsupportedNetwork},
+	}
+
+	for i, c := range cases {
+		_, err := VaporAssemble(c.source, c.network)
+		if decodeErr, ok := err.(*util.DecodeError); !ok || decodeErr.Kind != c.wantKind {
+			t.Errorf("case #%d, got error %v, want %s", i, err, c.wantKind)
+		}
+	}
+}
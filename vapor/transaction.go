@@ -8,17 +8,75 @@ import (
 	"github.com/bytom/vapor/common/arithmetic"
 	"github.com/bytom/vapor/consensus"
 	"github.com/bytom/vapor/consensus/segwit"
+	"github.com/bytom/vapor/math/checked"
 	"github.com/bytom/vapor/protocol/bc"
 	"github.com/bytom/vapor/protocol/bc/types"
+	"github.com/bytom/vapor/protocol/vm"
 
 	"github.com/vapor-sdk/util"
 )
 
-// VaporDecodeRawTx decode raw transaction
+const chainName = "vapor"
+
+// VaporDecodeRawTx decode raw transaction, a JSON error envelope is returned
+// when the transaction can not be decoded
 func VaporDecodeRawTx(rawTransaction string) []byte {
-	var rawTx types.Tx
+	jsonTx, err := VaporDecodeRawTxWithError(rawTransaction)
+	if err != nil {
+		return util.MarshalErrorEnvelope(err)
+	}
+	return jsonTx
+}
+
+// VaporDecodeRawTxWithError decode raw transaction on mainnet, the returned
+// error is a *util.DecodeError
+func VaporDecodeRawTxWithError(rawTransaction string) ([]byte, error) {
+	return VaporDecodeRawTxWithOptions(rawTransaction, util.DecodeOptions{})
+}
+
+// VaporDecodeRawTxWithOptions decode raw transaction with the given options,
+// the returned error is a *util.DecodeError
+func VaporDecodeRawTxWithOptions(rawTransaction string, opts util.DecodeOptions) ([]byte, error) {
+	tx, err := VaporDecodeTx(rawTransaction, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonTx, err := util.MarshalTransaction(tx, opts)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindMarshal, chainName, err)
+	}
+	return jsonTx, nil
+}
+
+// VaporDecodeTx decode raw transaction into the annotated transaction, the
+// returned error is a *util.DecodeError
+func VaporDecodeTx(rawTransaction string, opts util.DecodeOptions) (*util.Transaction, error) {
+	rawTx, err := VaporParseRawTx(rawTransaction)
+	if err != nil {
+		return nil, err
+	}
+	return VaporAnnotateTx(rawTx, opts)
+}
+
+// VaporParseRawTx parse raw transaction into the underlying types.Tx, the
+// returned error is a *util.DecodeError
+func VaporParseRawTx(rawTransaction string) (*types.Tx, error) {
+	rawTx := &types.Tx{}
 	if err := rawTx.UnmarshalText([]byte(rawTransaction)); err != nil {
-		return nil
+		return nil, util.NewRawTxError(chainName, rawTransaction, err, func(hexTx []byte) error {
+			return new(types.TxData).UnmarshalText(hexTx)
+		})
+	}
+	return rawTx, nil
+}
+
+// VaporAnnotateTx build the annotated transaction of a parsed types.Tx, the
+// returned error is a *util.DecodeError
+func VaporAnnotateTx(rawTx *types.Tx, opts util.DecodeOptions) (*util.Transaction, error) {
+	netParams, err := getNetParams(opts.Network)
+	if err != nil {
+		return nil, err
 	}
 
 	tx := &util.Transaction{
@@ -31,37 +89,45 @@ func VaporDecodeRawTx(rawTransaction string) []byte {
 	}
 
 	for i := range rawTx.Inputs {
-		tx.Inputs = append(tx.Inputs, buildAnnotatedInput(&rawTx, uint32(i)))
+		in := buildAnnotatedInput(rawTx, uint32(i), netParams)
+		if opts.VerifySignatures {
+			verifyInputWitness(rawTx, uint32(i), &in)
+		}
+		tx.Inputs = append(tx.Inputs, in)
 	}
 	for i := range rawTx.Outputs {
-		tx.Outputs = append(tx.Outputs, buildAnnotatedOutput(&rawTx, i))
+		tx.Outputs = append(tx.Outputs, buildAnnotatedOutput(rawTx, i, netParams))
 	}
 
-	txFee, err := arithmetic.CalculateTxFee(&rawTx)
-	if err != nil {
-		return nil
-	}
-	tx.Fee = int64(txFee)
-	jsonTx, err := json.Marshal(tx)
-	if err != nil {
-		return nil
+	txFee, err := arithmetic.CalculateTxFee(rawTx)
+	switch {
+	case err == checked.ErrOverflow:
+		tx.FeeOverflow = true
+	case err != nil:
+		return nil, util.NewDecodeError(util.ErrKindFee, chainName, err)
+	default:
+		tx.Fee = txFee
 	}
-	return jsonTx
+	return tx, nil
 }
 
 // buildAnnotatedInput build the annotated input.
-func buildAnnotatedInput(tx *types.Tx, i uint32) util.AnnotatedInput {
+func buildAnnotatedInput(tx *types.Tx, i uint32, netParams *consensus.Params) util.AnnotatedInput {
 	orig := tx.Inputs[i]
 	in := util.AnnotatedInput{}
 	if orig.InputType() != types.CoinbaseInputType {
 		assetID := orig.AssetID()
 		in.AssetID = assetID.String()
-		in.Amount = int64(orig.Amount())
+		in.Amount = orig.Amount()
 		signData := tx.SigHash(i)
 		in.SignData = signData.String()
 		if vetoInput, ok := orig.TypedInput.(*types.VetoInput); ok {
 			in.Vote = hex.EncodeToString(vetoInput.Vote)
 		}
+		if sc := getSpendCommitment(orig); sc != nil {
+			in.SourceID = sc.SourceID.String()
+			in.SourcePosition = sc.SourcePosition
+		}
 	} else {
 		in.AssetID = consensus.BTMAssetID.String()
 	}
@@ -74,7 +140,9 @@ func buildAnnotatedInput(tx *types.Tx, i uint32) util.AnnotatedInput {
 		in.Type = "veto"
 		controlProgram := orig.ControlProgram()
 		in.ControlProgram = hex.EncodeToString(controlProgram)
-		in.Address = getAddressFromControlProgram(controlProgram, false)
+		in.Address = getAddressFromControlProgram(controlProgram, false, netParams)
+		in.ScriptAsm = disassembleProgram(controlProgram)
+		in.ScriptType = getScriptType(controlProgram)
 		in.SpentOutputID = e.SpentOutputId.String()
 		arguments := orig.Arguments()
 		for _, arg := range arguments {
@@ -85,8 +153,22 @@ func buildAnnotatedInput(tx *types.Tx, i uint32) util.AnnotatedInput {
 		in.Type = "cross_chain_in"
 		controlProgram := orig.ControlProgram()
 		in.ControlProgram = hex.EncodeToString(controlProgram)
-		in.Address = getAddressFromControlProgram(controlProgram, true)
+		in.Address = getAddressFromControlProgram(controlProgram, true, netParams)
+		in.ScriptAsm = disassembleProgram(controlProgram)
+		in.ScriptType = getScriptType(controlProgram)
 		in.SpentOutputID = e.MainchainOutputId.String()
+		if crossChainInput, ok := orig.TypedInput.(*types.CrossChainInput); ok {
+			in.IssuanceProgram = hex.EncodeToString(crossChainInput.IssuanceProgram)
+			in.IssuanceProgramAsm = disassembleProgram(crossChainInput.IssuanceProgram)
+			in.IssuanceProgramType = getScriptType(crossChainInput.IssuanceProgram)
+			in.IssuanceVMVersion = crossChainInput.IssuanceVMVersion
+			in.AssetDefinition = hex.EncodeToString(crossChainInput.AssetDefinition)
+			if isValidJSON(crossChainInput.AssetDefinition) {
+				in.ParsedAssetDefinition = json.RawMessage(crossChainInput.AssetDefinition)
+			}
+			isOpenFederationIssue := common.IsOpenFederationIssueAsset(crossChainInput.AssetDefinition)
+			in.OpenFederationIssue = &isOpenFederationIssue
+		}
 		arguments := orig.Arguments()
 		for _, arg := range arguments {
 			in.WitnessArguments = append(in.WitnessArguments, hex.EncodeToString(arg))
@@ -96,12 +178,17 @@ func buildAnnotatedInput(tx *types.Tx, i uint32) util.AnnotatedInput {
 		in.Type = "spend"
 		controlProgram := orig.ControlProgram()
 		in.ControlProgram = hex.EncodeToString(controlProgram)
-		in.Address = getAddressFromControlProgram(controlProgram, false)
+		in.Address = getAddressFromControlProgram(controlProgram, false, netParams)
+		in.ScriptAsm = disassembleProgram(controlProgram)
+		in.ScriptType = getScriptType(controlProgram)
 		in.SpentOutputID = e.SpentOutputId.String()
 		arguments := orig.Arguments()
 		for _, arg := range arguments {
 			in.WitnessArguments = append(in.WitnessArguments, hex.EncodeToString(arg))
 		}
+		if in.Order = getOrder(controlProgram, netParams); in.Order != nil {
+			in.Order.Clause = getOrderClause(arguments)
+		}
 
 	case *bc.Coinbase:
 		in.Type = "coinbase"
@@ -110,22 +197,63 @@ func buildAnnotatedInput(tx *types.Tx, i uint32) util.AnnotatedInput {
 	return in
 }
 
+// getSpendCommitment returns the spend commitment of the input, or nil if the
+// input does not spend an output
+func getSpendCommitment(orig *types.TxInput) *types.SpendCommitment {
+	switch input := orig.TypedInput.(type) {
+	case *types.SpendInput:
+		return &input.SpendCommitment
+	case *types.VetoInput:
+		return &input.SpendCommitment
+	case *types.CrossChainInput:
+		return &input.SpendCommitment
+	}
+	return nil
+}
+
+// verifyInputWitness set the signature status of the input at i, inputs which
+// are not signed by a key are left out.
+func verifyInputWitness(tx *types.Tx, i uint32, in *util.AnnotatedInput) {
+	orig := tx.Inputs[i]
+	var program []byte
+	switch orig.InputType() {
+	case types.SpendInputType, types.VetoInputType:
+		program = orig.ControlProgram()
+	default:
+		return
+	}
+
+	status, err := util.VerifyWitness(program, orig.Arguments(), tx.SigHash(i).Byte32())
+	in.SignatureStatus = status
+	if err != nil {
+		in.SignatureError = err.Error()
+	}
+}
+
 // buildAnnotatedOutput build the annotated output.
-func buildAnnotatedOutput(tx *types.Tx, idx int) util.AnnotatedOutput {
+func buildAnnotatedOutput(tx *types.Tx, idx int, netParams *consensus.Params) util.AnnotatedOutput {
 	orig := tx.Outputs[idx]
 	outid := tx.OutputID(idx)
 	out := util.AnnotatedOutput{
 		OutputID:       outid.String(),
 		Position:       idx,
 		AssetID:        orig.AssetAmount().AssetId.String(),
-		Amount:         int64(orig.AssetAmount().Amount),
+		Amount:         orig.AssetAmount().Amount,
 		ControlProgram: hex.EncodeToString(orig.ControlProgram()),
+		ScriptAsm:      disassembleProgram(orig.ControlProgram()),
+		ScriptType:     getScriptType(orig.ControlProgram()),
 	}
 
 	var isMainchainAddress bool
 	switch e := tx.Entries[*outid].(type) {
+	case *bc.Retirement:
+		out.Type = "retire"
+		out.RetireComment = hex.EncodeToString(getRetireComment(orig.ControlProgram()))
+		isMainchainAddress = false
+
 	case *bc.IntraChainOutput:
 		out.Type = "control"
+		out.Order = getOrder(orig.ControlProgram(), netParams)
 		isMainchainAddress = false
 
 	case *bc.CrossChainOutput:
@@ -138,14 +266,29 @@ func buildAnnotatedOutput(tx *types.Tx, idx int) util.AnnotatedOutput {
 		isMainchainAddress = false
 	}
 
-	out.Address = getAddressFromControlProgram(orig.ControlProgram(), isMainchainAddress)
+	out.Address = getAddressFromControlProgram(orig.ControlProgram(), isMainchainAddress, netParams)
 	return out
 }
 
-func getAddressFromControlProgram(prog []byte, isMainchain bool) string {
-	netParams := &consensus.MainNetParams
+func isValidJSON(b []byte) bool {
+	var v interface{}
+	err := json.Unmarshal(b, &v)
+	return err == nil
+}
+
+// getRetireComment returns the comment that vmutil.RetireProgram embeds after
+// OP_FAIL, or nil if the program carries none
+func getRetireComment(prog []byte) []byte {
+	insts, err := vm.ParseProgram(prog)
+	if err != nil || len(insts) != 2 || insts[0].Op != vm.OP_FAIL || !insts[1].IsPushdata() {
+		return nil
+	}
+	return insts[1].Data
+}
+
+func getAddressFromControlProgram(prog []byte, isMainchain bool, netParams *consensus.Params) string {
 	if isMainchain {
-		netParams = consensus.BytomMainNetParams(&consensus.MainNetParams)
+		netParams = consensus.BytomMainNetParams(netParams)
 	}
 	if segwit.IsP2WPKHScript(prog) {
 		if pubHash, err := segwit.GetHashFromStandardProg(prog); err == nil {
@@ -1,11 +1,16 @@
 package transaction
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"testing"
 
 	"github.com/bytom/bytom/testutil"
+	"github.com/bytom/vapor/consensus"
+	"github.com/bytom/vapor/protocol/bc"
+	"github.com/bytom/vapor/protocol/bc/types"
+	"github.com/bytom/vapor/protocol/vm/vmutil"
 
 	"github.com/vapor-sdk/util"
 )
@@ -30,8 +35,11 @@ func TestVaporDecodeRawTx(t *testing.T) {
 						AssetID:          "bb16babcc936f9a7467bc9f615be17cb69809aa7cefd4287d4098690585b3991",
 						Amount:           10000000,
 						ControlProgram:   "00144b6995dc11354d44c6e382c19d6b92bdbbd3aea1",
+						ScriptAsm:        "FALSE 0x4b6995dc11354d44c6e382c19d6b92bdbbd3aea1",
+						ScriptType:       "p2wpkh",
 						Address:          "vp1qfd5ethq3x4x5f3hrstqe66ujhkaa8t4p8vud4p",
 						SpentOutputID:    "873cd20c2cd260e1d2902f173bbc32490a9aa184b8e47aaedf3f37d7bf5225dd",
+						SourceID:         "13c41cc617304ba0866fa59f07d7bb2bcab60c43e5cc79bb75a4dd97471cdcba",
 						Arbitrary:        "",
 						WitnessArguments: nil,
 						SignData:         "96b1454d0ca5fd05f321345149ab526ad14be9ae364fdb6e6bda5825b4e1c388",
@@ -45,6 +53,8 @@ func TestVaporDecodeRawTx(t *testing.T) {
 						AssetID:        "bb16babcc936f9a7467bc9f615be17cb69809aa7cefd4287d4098690585b3991",
 						Amount:         5000000,
 						ControlProgram: "00149682e64b2114f7c2581ab1ba0c67315d06aaea82",
+						ScriptAsm:      "FALSE 0x9682e64b2114f7c2581ab1ba0c67315d06aaea82",
+						ScriptType:     "p2wpkh",
 						Address:        "vp1qj6pwvjepznmuykq6kxaqcee3t5r2465z0hmr70",
 						Vote:           "",
 					},
@@ -55,6 +65,8 @@ func TestVaporDecodeRawTx(t *testing.T) {
 						AssetID:        "bb16babcc936f9a7467bc9f615be17cb69809aa7cefd4287d4098690585b3991",
 						Amount:         5000000,
 						ControlProgram: "0014da26416fa79947ec6a569e0493dbffec1a3f2234",
+						ScriptAsm:      "FALSE 0xda26416fa79947ec6a569e0493dbffec1a3f2234",
+						ScriptType:     "p2wpkh",
 						Address:        "vp1qmgnyzma8n9r7c6jknczf8kllasdr7g35whjwpg",
 						Vote:           "",
 					},
@@ -76,8 +88,12 @@ func TestVaporDecodeRawTx(t *testing.T) {
 						AssetID:          "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
 						Amount:           10000,
 						ControlProgram:   "0014d66216efa3177397973c6e173f8f7f17a7b64b81",
+						ScriptAsm:        "FALSE 0xd66216efa3177397973c6e173f8f7f17a7b64b81",
+						ScriptType:       "p2wpkh",
 						Address:          "vp1q6e3pdmarzaee09eudctnlrmlz7nmvjup8wtqxd",
 						SpentOutputID:    "933d1e2e7a1317f25ee1f75de6abf93867100c4190a9e3d2c4abe3485ebe63b7",
+						SourceID:         "bfa8cb0c58b545bf844dd642b6b5333ac76b4b789b3795a129a93a9fe47c3227",
+						SourcePosition:   1,
 						Arbitrary:        "",
 						WitnessArguments: nil,
 						SignData:         "6e142176d423e825f27971c928ca09e174fc7e8134428c19b3a33e7d6a7abfac",
@@ -91,6 +107,8 @@ func TestVaporDecodeRawTx(t *testing.T) {
 						AssetID:        "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
 						Amount:         10000,
 						ControlProgram: "0014d66216efa3177397973c6e173f8f7f17a7b64b81",
+						ScriptAsm:      "FALSE 0xd66216efa3177397973c6e173f8f7f17a7b64b81",
+						ScriptType:     "p2wpkh",
 						Address:        "vp1q6e3pdmarzaee09eudctnlrmlz7nmvjup8wtqxd",
 						Vote:           "",
 					},
@@ -112,8 +130,12 @@ func TestVaporDecodeRawTx(t *testing.T) {
 						AssetID:        "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
 						Amount:         120000000000000000,
 						ControlProgram: "0014973616e27ba7468f3a54820c97ab1b22094bd42d",
+						ScriptAsm:      "FALSE 0x973616e27ba7468f3a54820c97ab1b22094bd42d",
+						ScriptType:     "p2wpkh",
 						Address:        "vp1qjumpdcnm5arg7wj5sgxf02cmygy5h4pde4aynj",
 						SpentOutputID:  "fcf9d0fae86697cd396d81a60cbd296f74ba337d76240d12f7baf3f1e548f771",
+						SourceID:       "8a3e00e2f6cfe2765fd0b51201d3d5e44ba461aa3cd57306068b7bdf0d4a105d",
+						SourcePosition: 1,
 						WitnessArguments: []string{
 							"d8f36726bf7e69a01afdf05251a2338fb8c2595d881898b5903302d32619185f41c90990e7160593fd4dc416fb38b3845f32277685028e52f01fa98a4d121a07",
 							"fbbb8233f1435c2c0ab26ee4aeb94e534490c65a48e253a5dc64cad835462d29",
@@ -129,6 +151,8 @@ func TestVaporDecodeRawTx(t *testing.T) {
 						AssetID:        "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
 						Amount:         119900000000000000,
 						ControlProgram: "00140bcc5b6e8f2cb3390cf6d45fca37ed8606253601",
+						ScriptAsm:      "FALSE 0x0bcc5b6e8f2cb3390cf6d45fca37ed8606253601",
+						ScriptType:     "p2wpkh",
 						Address:        "vp1qp0x9km509jenjr8k630u5dldscrz2dsp8vafzs",
 					},
 					util.AnnotatedOutput{
@@ -138,6 +162,8 @@ func TestVaporDecodeRawTx(t *testing.T) {
 						AssetID:        "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
 						Amount:         100000000000000,
 						ControlProgram: "00143eb3371ee17bfa7d1e6af07c2e1fc08b3b1177ad",
+						ScriptAsm:      "FALSE 0x3eb3371ee17bfa7d1e6af07c2e1fc08b3b1177ad",
+						ScriptType:     "p2wpkh",
 						Address:        "vp1q86enw8hp00a868n27p7zu87q3va3zaady6805f",
 						Vote:           "9742a39a0bcfb5b7ac8f56f1894fbb694b53ebf58f9a032c36cc22d57a06e49e94ff7199063fb7a78190624fa3530f611404b56fc9af91dcaf4639614512cb64",
 					},
@@ -163,3 +189,194 @@ func TestVaporDecodeRawTx(t *testing.T) {
 		}
 	}
 }
+
+func TestVaporDecodeRawTxWithError(t *testing.T) {
+	rawTransaction := `07010001015d015bbfa8cb0c58b545bf844dd642b6b5333ac76b4b789b3795a129a93a9fe47c3227ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff904e0101160014d66216efa3177397973c6e173f8f7f17a7b64b81010001013c003affffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff904e01160014d66216efa3177397973c6e173f8f7f17a7b64b8100`
+	cases := []struct {
+		desc           string
+		rawTransaction string
+		wantKind       util.ErrorKind
+		wantOffset     int
+	}{
+		{
+			desc:           "invalid hex character",
+			rawTransaction: "070100zz",
+			wantKind:       util.ErrKindInvalidHex,
+			wantOffset:     3,
+		},
+		{
+			desc:           "odd length hex",
+			rawTransaction: rawTransaction[:len(rawTransaction)-1],
+			wantKind:       util.ErrKindInvalidHex,
+			wantOffset:     164,
+		},
+		{
+			desc:           "unsupported serialization flags",
+			rawTransaction: "08" + rawTransaction[2:],
+			wantKind:       util.ErrKindMalformedTx,
+			wantOffset:     0,
+		},
+		{
+			desc:           "truncated input",
+			rawTransaction: rawTransaction[:60],
+			wantKind:       util.ErrKindMalformedTx,
+			wantOffset:     4,
+		},
+		{
+			desc:           "unsupported output type",
+			rawTransaction: rawTransaction[:208] + "09" + rawTransaction[210:],
+			wantKind:       util.ErrKindMalformedTx,
+			wantOffset:     102,
+		},
+		{
+			desc:           "trailing garbage",
+			rawTransaction: rawTransaction + "00",
+			wantKind:       util.ErrKindMalformedTx,
+			wantOffset:     165,
+		},
+	}
+
+	for i, c := range cases {
+		_, err := VaporDecodeRawTxWithError(c.rawTransaction)
+		decodeErr, ok := err.(*util.DecodeError)
+		if !ok {
+			t.Fatalf("case #%d (%s), got error %v, want *util.DecodeError", i, c.desc, err)
+		}
+
+		if decodeErr.Kind != c.wantKind || decodeErr.Offset != c.wantOffset || decodeErr.Chain != "vapor" {
+			t.Errorf("case #%d (%s), got kind=%s offset=%d chain=%s, want kind=%s offset=%d chain=vapor", i, c.desc, decodeErr.Kind, decodeErr.Offset, decodeErr.Chain, c.wantKind, c.wantOffset)
+		}
+	}
+}
+
+func TestVaporDecodeRawTxNetwork(t *testing.T) {
+	rawTransaction := `07010001015d015bbfa8cb0c58b545bf844dd642b6b5333ac76b4b789b3795a129a93a9fe47c3227ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff904e0101160014d66216efa3177397973c6e173f8f7f17a7b64b81010001013c003affffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff904e01160014d66216efa3177397973c6e173f8f7f17a7b64b8100`
+	cases := []struct {
+		network     string
+		wantAddress string
+		wantKind    util.ErrorKind
+	}{
+		{
+			network:     "",
+			wantAddress: "vp1q6e3pdmarzaee09eudctnlrmlz7nmvjup8wtqxd",
+		},
+		{
+			network:     "testnet",
+			wantAddress: "tp1q6e3pdmarzaee09eudctnlrmlz7nmvjup2vzdxe",
+		},
+		{
+			network:     "solonet",
+			wantAddress: "sp1q6e3pdmarzaee09eudctnlrmlz7nmvjup0tfgxx",
+		},
+		{
+			network:  "wisdom",
+			wantKind: util.ErrKindUnsupportedNetwork,
+		},
+	}
+
+	for i, c := range cases {
+		jsonTx, err := VaporDecodeRawTxWithOptions(rawTransaction, util.DecodeOptions{Network: c.network})
+		if c.wantKind != "" {
+			if decodeErr, ok := err.(*util.DecodeError); !ok || decodeErr.Kind != c.wantKind {
+				t.Errorf("case #%d, got error %v, want kind %s", i, err, c.wantKind)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		gotTx := &util.Transaction{}
+		if err := json.Unmarshal(jsonTx, gotTx); err != nil {
+			t.Fatal(err)
+		}
+
+		if gotTx.Inputs[0].Address != c.wantAddress || gotTx.Outputs[0].Address != c.wantAddress {
+			t.Errorf("case #%d, got addresses %s %s, want %s", i, gotTx.Inputs[0].Address, gotTx.Outputs[0].Address, c.wantAddress)
+		}
+	}
+}
+
+func TestVaporDecodeTxRetirement(t *testing.T) {
+	controlProgram := testutil.MustDecodeHexString("0014d66216efa3177397973c6e173f8f7f17a7b64b81")
+	cases := []struct {
+		comment []byte
+	}{
+		{comment: nil},
+		{comment: []byte("burn for fee reward")},
+	}
+
+	for i, c := range cases {
+		retireProgram, err := vmutil.RetireProgram(c.comment)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rawTx := types.NewTx(types.TxData{
+			Version: 1,
+			Inputs:  []*types.TxInput{types.NewSpendInput(nil, bc.NewHash([32]byte{1}), *consensus.BTMAssetID, 10000, 0, controlProgram)},
+			Outputs: []*types.TxOutput{types.NewIntraChainOutput(*consensus.BTMAssetID, 10000, retireProgram)},
+		})
+
+		gotTx, err := VaporAnnotateTx(rawTx, util.DecodeOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := gotTx.Outputs[0]
+		if out.Type != "retire" || out.RetireComment != hex.EncodeToString(c.comment) || out.Address != "" {
+			t.Errorf("case #%d, got output type=%s comment=%s address=%s, want retire output with comment %x", i, out.Type, out.RetireComment, out.Address, c.comment)
+		}
+	}
+}
+
+func TestVaporDecodeTxCrossChainInput(t *testing.T) {
+	controlProgram := testutil.MustDecodeHexString("0014d66216efa3177397973c6e173f8f7f17a7b64b81")
+	issuanceProgram := testutil.MustDecodeHexString("ae2054a71277cc162eb3eb21b5bd9fe54402829a53b294deaed91692a2cd8a081f9c5151ad")
+	cases := []struct {
+		assetDefinition  []byte
+		wantParsed       string
+		wantOpenFedIssue bool
+	}{
+		{
+			assetDefinition:  []byte(`{"decimals":8,"description":{"issue_asset_action":"open_federation_cross_chain"},"name":"USDT"}`),
+			wantParsed:       `{"decimals":8,"description":{"issue_asset_action":"open_federation_cross_chain"},"name":"USDT"}`,
+			wantOpenFedIssue: true,
+		},
+		{
+			assetDefinition:  []byte(`{"decimals":8,"description":{},"name":"ETH"}`),
+			wantParsed:       `{"decimals":8,"description":{},"name":"ETH"}`,
+			wantOpenFedIssue: false,
+		},
+		{
+			assetDefinition:  []byte("not json"),
+			wantParsed:       "",
+			wantOpenFedIssue: false,
+		},
+	}
+
+	for i, c := range cases {
+		assetID := bc.NewAssetID([32]byte{byte(i + 1)})
+		rawTx := types.NewTx(types.TxData{
+			Version: 1,
+			Inputs:  []*types.TxInput{types.NewCrossChainInput(nil, bc.NewHash([32]byte{1}), assetID, 10000, 0, 1, c.assetDefinition, issuanceProgram)},
+			Outputs: []*types.TxOutput{types.NewIntraChainOutput(assetID, 10000, controlProgram)},
+		})
+
+		gotTx, err := VaporAnnotateTx(rawTx, util.DecodeOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		in := gotTx.Inputs[0]
+		if in.Type != "cross_chain_in" || in.IssuanceProgram != hex.EncodeToString(issuanceProgram) || in.IssuanceVMVersion != 1 || in.AssetDefinition != hex.EncodeToString(c.assetDefinition) {
+			t.Errorf("case #%d, got input %#v", i, in)
+		}
+		if string(in.ParsedAssetDefinition) != c.wantParsed {
+			t.Errorf("case #%d, got parsed asset definition %s, want %s", i, in.ParsedAssetDefinition, c.wantParsed)
+		}
+		if in.OpenFederationIssue == nil || *in.OpenFederationIssue != c.wantOpenFedIssue {
+			t.Errorf("case #%d, got open federation issue %v, want %t", i, in.OpenFederationIssue, c.wantOpenFedIssue)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package transaction
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/bytom/vapor/consensus/segwit"
+	"github.com/bytom/vapor/crypto/sha3pool"
+	"github.com/bytom/vapor/protocol/bc"
+	"github.com/bytom/vapor/protocol/bc/types"
+	"github.com/bytom/vapor/protocol/vm"
+
+	"github.com/vapor-sdk/util"
+)
+
+// VaporValidateRawTx validate raw transaction offline and return the
+// util.ValidationResult as JSON, the returned error is a *util.DecodeError
+func VaporValidateRawTx(rawTransaction string, opts util.ValidateOptions) ([]byte, error) {
+	rawTx, err := VaporParseRawTx(rawTransaction)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := VaporValidateTx(rawTx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonResult, err := json.Marshal(result)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindMarshal, chainName, err)
+	}
+	return jsonResult, nil
+}
+
+// VaporValidateTx run the program of every input through the VM in a
+// synthetic block built from opts, and check the transaction level rules of
+// the vapor node which need no chain state: version, time range, balance and
+// gas. The gas of the fee is spent by the inputs in order. Cross-chain inputs
+// are skipped as the federation program is not known offline. The VM of vapor
+// does not see the block header, so opts.Timestamp must be 0. The returned
+// error is a *util.DecodeError
+func VaporValidateTx(rawTx *types.Tx, opts util.ValidateOptions) (*util.ValidationResult, error) {
+	netParams, err := getNetParams(opts.Network)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Timestamp != 0 {
+		return nil, util.NewDecodeError(util.ErrKindInvalidTx, chainName, errors.New("timestamp of the synthetic block is not used by vapor"))
+	}
+
+	result := &util.ValidationResult{
+		TxID:       rawTx.ID.String(),
+		StorageGas: int64(rawTx.SerializedSize) * netParams.StorageGasRate,
+		Inputs:     []util.InputValidation{},
+	}
+
+	isCoinbase := len(rawTx.Inputs) > 0 && rawTx.Inputs[0].InputType() == types.CoinbaseInputType
+	var fee uint64
+	var balanceErr error
+	if isCoinbase {
+		result.StorageGas = 0
+	} else {
+		fee, balanceErr = checkBalance(rawTx)
+	}
+
+	gasLeft := int64(fee / uint64(netParams.VMGasRate))
+	if gasLeft > netParams.MaxGasAmount {
+		gasLeft = netParams.MaxGasAmount
+	}
+	gasAvailable := gasLeft
+
+	for i, id := range rawTx.Tx.InputIDs {
+		in := validateInput(rawTx.Tx, rawTx.Entries[id], opts.BlockHeight, gasLeft)
+		in.Position = i
+		gasLeft = in.GasLeft
+		result.GasUsed += in.GasUsed
+		result.Inputs = append(result.Inputs, in)
+	}
+	result.GasUsed += result.StorageGas
+	result.GasLeft = gasAvailable - result.GasUsed
+
+	switch {
+	case rawTx.Version != 1:
+		err = fmt.Errorf("invalid transaction version %d", rawTx.Version)
+	case rawTx.SerializedSize == 0:
+		err = errors.New("invalid transaction size")
+	case rawTx.TimeRange != 0 && rawTx.TimeRange < opts.BlockHeight:
+		err = fmt.Errorf("time range %d expired at height %d", rawTx.TimeRange, opts.BlockHeight)
+	case balanceErr != nil:
+		err = balanceErr
+	case result.GasLeft < 0:
+		err = fmt.Errorf("gas used %d exceeds the %d gas paid by the fee", result.GasUsed, gasAvailable)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	for _, in := range result.Inputs {
+		if in.Status == util.ValidationFail {
+			result.Error = fmt.Sprintf("input %d: %s", in.Position, in.Error)
+			return result, nil
+		}
+	}
+	result.Valid = true
+	return result, nil
+}
+
+// validateInput run the program of an input entry through the VM with at most
+// gasLimit gas
+func validateInput(tx *bc.Tx, entry bc.Entry, blockHeight uint64, gasLimit int64) util.InputValidation {
+	in := util.InputValidation{Status: util.ValidationSkipped, GasLeft: gasLimit}
+	var prog *bc.Program
+	var args [][]byte
+	switch e := entry.(type) {
+	case *bc.Spend:
+		in.Type = "spend"
+		spentOutput, err := tx.IntraChainOutput(*e.SpentOutputId)
+		if err != nil {
+			in.Status, in.Error = util.ValidationFail, err.Error()
+			return in
+		}
+		prog, args = spentOutput.ControlProgram, e.WitnessArguments
+
+	case *bc.VetoInput:
+		in.Type = "veto"
+		spentOutput, err := tx.VoteOutput(*e.SpentOutputId)
+		if err != nil {
+			in.Status, in.Error = util.ValidationFail, err.Error()
+			return in
+		}
+		prog, args = spentOutput.ControlProgram, e.WitnessArguments
+
+	case *bc.CrossChainInput:
+		in.Type = "cross_chain_in"
+		return in
+
+	case *bc.Coinbase:
+		in.Type = "coinbase"
+		return in
+
+	default:
+		return in
+	}
+
+	gasLeft, err := vm.Verify(newTxVMContext(tx, entry, prog, args, blockHeight), gasLimit)
+	in.GasUsed, in.GasLeft = gasLimit-gasLeft, gasLeft
+	if err != nil {
+		in.Status, in.Error = util.ValidationFail, err.Error()
+		return in
+	}
+	in.Status = util.ValidationPass
+	return in
+}
+
+// newTxVMContext builds the vm.Context of a spend or veto input like the
+// validation package of the vapor node
+func newTxVMContext(tx *bc.Tx, entry bc.Entry, prog *bc.Program, args [][]byte, blockHeight uint64) *vm.Context {
+	var (
+		numResults = uint64(len(tx.ResultIds))
+		entryID    = bc.EntryID(entry)
+
+		assetID       *[]byte
+		amount        *uint64
+		destPos       *uint64
+		spentOutputID *[]byte
+	)
+
+	switch e := entry.(type) {
+	case *bc.Spend:
+		spentOutput := tx.Entries[*e.SpentOutputId].(*bc.IntraChainOutput)
+		a1 := spentOutput.Source.Value.AssetId.Bytes()
+		assetID = &a1
+		amount = &spentOutput.Source.Value.Amount
+		destPos = &e.WitnessDestination.Position
+		s := e.SpentOutputId.Bytes()
+		spentOutputID = &s
+
+	case *bc.VetoInput:
+		spentOutput := tx.Entries[*e.SpentOutputId].(*bc.VoteOutput)
+		a1 := spentOutput.Source.Value.AssetId.Bytes()
+		assetID = &a1
+		amount = &spentOutput.Source.Value.Amount
+		destPos = &e.WitnessDestination.Position
+		s := e.SpentOutputId.Bytes()
+		spentOutputID = &s
+	}
+
+	txSigHashFn := func() []byte {
+		hasher := sha3pool.Get256()
+		defer sha3pool.Put256(hasher)
+
+		entryID.WriteTo(hasher)
+		tx.ID.WriteTo(hasher)
+
+		var hash bc.Hash
+		hash.ReadFrom(hasher)
+		return hash.Bytes()
+	}
+
+	ec := &entryContext{entry: entry, entries: tx.Entries}
+	return &vm.Context{
+		VMVersion: prog.VmVersion,
+		Code:      witnessProgram(prog.Code),
+		Arguments: args,
+
+		EntryID: entryID.Bytes(),
+
+		TxVersion:   &tx.Version,
+		BlockHeight: &blockHeight,
+
+		TxSigHash:     txSigHashFn,
+		NumResults:    &numResults,
+		AssetID:       assetID,
+		Amount:        amount,
+		DestPos:       destPos,
+		SpentOutputID: spentOutputID,
+		CheckOutput:   ec.checkOutput,
+	}
+}
+
+// witnessProgram expands a segwit program into the program the VM runs
+func witnessProgram(prog []byte) []byte {
+	switch {
+	case segwit.IsP2WPKHScript(prog):
+		if witnessProg, err := segwit.ConvertP2PKHSigProgram(prog); err == nil {
+			return witnessProg
+		}
+	case segwit.IsP2WSHScript(prog):
+		if witnessProg, err := segwit.ConvertP2SHProgram(prog); err == nil {
+			return witnessProg
+		}
+	case segwit.IsP2WMCScript(prog):
+		if witnessProg, err := segwit.ConvertP2MCProgram(prog); err == nil {
+			return witnessProg
+		}
+	}
+	return prog
+}
+
+type entryContext struct {
+	entry   bc.Entry
+	entries map[bc.Hash]bc.Entry
+}
+
+// checkOutput implements OP_CHECKOUTPUT like the entry context of the vapor
+// node
+func (ec *entryContext) checkOutput(index uint64, amount uint64, assetID []byte, vmVersion uint64, code []byte, expansion bool) (bool, error) {
+	checkEntry := func(e bc.Entry) (bool, error) {
+		check := func(prog *bc.Program, value *bc.AssetAmount) bool {
+			return (prog.VmVersion == vmVersion &&
+				bytes.Equal(prog.Code, code) &&
+				bytes.Equal(value.AssetId.Bytes(), assetID) &&
+				value.Amount == amount)
+		}
+
+		switch e := e.(type) {
+		case *bc.IntraChainOutput:
+			return check(e.ControlProgram, e.Source.Value), nil
+
+		case *bc.CrossChainOutput:
+			return check(e.ControlProgram, e.Source.Value), nil
+
+		case *bc.VoteOutput:
+			return check(e.ControlProgram, e.Source.Value), nil
+
+		case *bc.Retirement:
+			var prog bc.Program
+			if expansion {
+				prog.Code = code
+			}
+			return check(&prog, e.Source.Value), nil
+		}
+		return false, vm.ErrContext
+	}
+
+	checkMux := func(m *bc.Mux) (bool, error) {
+		if index >= uint64(len(m.WitnessDestinations)) {
+			return false, fmt.Errorf("%v: index %d >= %d", vm.ErrBadValue, index, len(m.WitnessDestinations))
+		}
+		eID := m.WitnessDestinations[index].Ref
+		e, ok := ec.entries[*eID]
+		if !ok {
+			return false, fmt.Errorf("%v: entry for mux destination %d not found", bc.ErrMissingEntry, index)
+		}
+		return checkEntry(e)
+	}
+
+	var dest *bc.ValueDestination
+	switch e := ec.entry.(type) {
+	case *bc.Spend:
+		dest = e.WitnessDestination
+	case *bc.VetoInput:
+		dest = e.WitnessDestination
+	default:
+		return false, vm.ErrContext
+	}
+
+	d, ok := ec.entries[*dest.Ref]
+	if !ok {
+		return false, fmt.Errorf("%v: entry for input destination not found", bc.ErrMissingEntry)
+	}
+	if m, ok := d.(*bc.Mux); ok {
+		return checkMux(m)
+	}
+	if index != 0 {
+		return false, fmt.Errorf("%v: index %d >= 1", vm.ErrBadValue, index)
+	}
+	return checkEntry(d)
+}
@@ -0,0 +1,112 @@
+package transaction
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/bytom/bytom/crypto/ed25519/chainkd"
+	"github.com/bytom/vapor/consensus"
+
+	"github.com/vapor-sdk/util"
+)
+
+func TestVaporValidateTx(t *testing.T) {
+	key, controlProgram := testP2WPKHKey(t, "alice")
+	multiSig, err := VaporMultiSig(testMultiSigKeys(t, "alice", "bob", "carol"), 2, "mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	btm := consensus.BTMAssetID.String()
+	tpl, err := VaporBuild([]Action{
+		&SpendUTXOAction{UTXO: util.UTXO{
+			SourceID:       "0100000000000000000000000000000000000000000000000000000000000000",
+			AssetID:        btm,
+			Amount:         100000000,
+			ControlProgram: hex.EncodeToString(controlProgram),
+			Keys:           []util.KeyID{key},
+		}},
+		&SpendUTXOAction{UTXO: util.UTXO{
+			SourceID:       "0200000000000000000000000000000000000000000000000000000000000000",
+			AssetID:        btm,
+			Amount:         100000000,
+			ControlProgram: multiSig.ControlProgram,
+			Keys:           multiSig.Keys,
+			Quorum:         multiSig.Quorum,
+		}},
+		&ControlAddressAction{AssetAmount: util.AssetAmount{AssetID: btm, Amount: 190000000}, Address: multiSig.Address},
+	}, 1000, "mainnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unsignedTx := tpl.RawTransaction
+	if _, err := VaporSignTemplate(tpl, util.XPrvSignFunc(chainkd.RootXPrv([]byte("alice")), chainkd.RootXPrv([]byte("bob")))); err != nil {
+		t.Fatal(err)
+	}
+	args := testArguments(t, tpl.RawTransaction, 0)
+
+	cases := []struct {
+		desc           string
+		rawTransaction string
+		opts           util.ValidateOptions
+		wantValid      bool
+		wantInputs     []string
+	}{
+		{
+			desc:           "signed",
+			rawTransaction: tpl.RawTransaction,
+			opts:           util.ValidateOptions{BlockHeight: 1000},
+			wantValid:      true,
+			wantInputs:     []string{util.ValidationPass, util.ValidationPass},
+		},
+		{
+			desc:           "unsigned",
+			rawTransaction: unsignedTx,
+			wantInputs:     []string{util.ValidationFail, util.ValidationFail},
+		},
+		{
+			desc:           "witness of another input",
+			rawTransaction: setTestArguments(t, tpl.RawTransaction, 1, args),
+			wantInputs:     []string{util.ValidationPass, util.ValidationFail},
+		},
+		{
+			desc:           "expired time range",
+			rawTransaction: tpl.RawTransaction,
+			opts:           util.ValidateOptions{BlockHeight: 1001},
+			wantInputs:     []string{util.ValidationPass, util.ValidationPass},
+		},
+	}
+
+	for _, c := range cases {
+		rawTx, err := VaporParseRawTx(c.rawTransaction)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := VaporValidateTx(rawTx, c.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", c.desc, err)
+		}
+
+		if result.Valid != c.wantValid || result.Valid != (result.Error == "") {
+			t.Errorf("%s: got valid %v (%s), want %v", c.desc, result.Valid, result.Error, c.wantValid)
+		}
+		for i, in := range result.Inputs {
+			if in.Status != c.wantInputs[i] || in.Type != "spend" || in.GasUsed <= 0 {
+				t.Errorf("%s: input #%d, got %#v, want %s", c.desc, i, in, c.wantInputs[i])
+			}
+		}
+		if result.StorageGas != int64(rawTx.SerializedSize) {
+			t.Errorf("%s: got storage gas %d, want %d", c.desc, result.StorageGas, rawTx.SerializedSize)
+		}
+	}
+
+	if _, err := VaporValidateRawTx(tpl.RawTransaction, util.ValidateOptions{Network: "moonnet"}); err == nil {
+		t.Error("unsupported network is accepted")
+	}
+	_, err = VaporValidateRawTx(tpl.RawTransaction, util.ValidateOptions{Timestamp: 1577836800000})
+	if decodeErr, ok := err.(*util.DecodeError); !ok || decodeErr.Kind != util.ErrKindInvalidTx {
+		t.Errorf("got error %v for a timestamp, want kind %s", err, util.ErrKindInvalidTx)
+	}
+}
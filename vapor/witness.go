@@ -0,0 +1,105 @@
+package transaction
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/bytom/bytom/crypto/ed25519/chainkd"
+	"github.com/bytom/vapor/protocol/bc/types"
+
+	"github.com/vapor-sdk/util"
+)
+
+var errNoConsensusNodes = errors.New("no consensus node xpub")
+
+// VerifyVaporBlockWitness verifies the signature of each consensus node over
+// the hash of header, the witness of the node of order i is at position i.
+// Witness entries beyond the nodes are ignored. The threshold is more than two
+// thirds of the nodes when it is 0, like the irreversible blocks of Vapor.
+func VerifyVaporBlockWitness(header *types.BlockHeader, xpubs []chainkd.XPub, threshold int) *util.BlockWitnessResult {
+	if threshold == 0 {
+		threshold = len(xpubs)*2/3 + 1
+	}
+
+	hash := header.Hash()
+	result := &util.BlockWitnessResult{
+		Hash:       hash.String(),
+		Height:     header.Height,
+		Signatures: []util.NodeSignature{},
+		Threshold:  threshold,
+	}
+	for i, xpub := range xpubs {
+		sig := util.NodeSignature{Order: i, XPub: xpub.String(), Status: util.SignatureMissing}
+		if signature := header.Get(uint64(i)); len(signature) != 0 {
+			sig.Signature = hex.EncodeToString(signature)
+			sig.Status = util.SignatureInvalid
+			if xpub.Verify(hash.Bytes(), signature) {
+				sig.Status = util.SignatureValid
+				result.SignCount++
+			}
+		}
+		result.Signatures = append(result.Signatures, sig)
+	}
+	result.ThresholdReached = result.SignCount >= threshold
+	return result
+}
+
+// VaporVerifyRawBlockWitness verify the witness of a raw block or block header
+// against the hex xpubs of the consensus nodes in their order, see
+// VerifyVaporBlockWitness. The returned error is a *util.DecodeError
+func VaporVerifyRawBlockWitness(rawBlock string, xpubs []string, threshold int) (*util.BlockWitnessResult, error) {
+	block, err := VaporParseRawBlock(rawBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	serflag, _ := hex.DecodeString(rawBlock[:2])
+	if serflag[0] == types.SerBlockTransactions {
+		return nil, util.NewDecodeError(util.ErrKindMalformedBlock, chainName, errNoBlockHeader)
+	}
+
+	if len(xpubs) == 0 {
+		return nil, util.NewDecodeError(util.ErrKindInvalidWitness, chainName, errNoConsensusNodes)
+	}
+	if threshold < 0 || threshold > len(xpubs) {
+		return nil, util.NewDecodeError(util.ErrKindInvalidWitness, chainName, fmt.Errorf("threshold %d out of range for %d consensus nodes", threshold, len(xpubs)))
+	}
+
+	var nodes []chainkd.XPub
+	seen := make(map[chainkd.XPub]bool)
+	for i, xpub := range xpubs {
+		var node chainkd.XPub
+		if err := node.UnmarshalText([]byte(xpub)); err != nil {
+			return nil, util.NewDecodeError(util.ErrKindInvalidKey, chainName, fmt.Errorf("xpub %d: %v", i, err))
+		}
+		if seen[node] {
+			return nil, util.NewDecodeError(util.ErrKindInvalidWitness, chainName, fmt.Errorf("duplicate consensus node xpub %s", xpub))
+		}
+		seen[node] = true
+		nodes = append(nodes, node)
+	}
+	return VerifyVaporBlockWitness(&block.BlockHeader, nodes, threshold), nil
+}
+
+// VaporVerifyBlockWitness verify the block witness of the JSON form of a
+// util.BlockWitnessRequest and return the util.BlockWitnessResult as JSON, the
+// returned error is a *util.DecodeError
+func VaporVerifyBlockWitness(jsonRequest []byte) ([]byte, error) {
+	req := &util.BlockWitnessRequest{}
+	if err := json.Unmarshal(jsonRequest, req); err != nil {
+		return nil, util.NewDecodeError(util.ErrKindInvalidWitness, chainName, err)
+	}
+
+	result, err := VaporVerifyRawBlockWitness(req.RawBlock, req.XPubs, req.Threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonResult, err := json.Marshal(result)
+	if err != nil {
+		return nil, util.NewDecodeError(util.ErrKindMarshal, chainName, err)
+	}
+	return jsonResult, nil
+}
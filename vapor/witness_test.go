@@ -0,0 +1,172 @@
+package transaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/bytom/bytom/crypto/ed25519/chainkd"
+	"github.com/bytom/vapor/protocol/bc"
+	"github.com/bytom/vapor/protocol/bc/types"
+
+	"github.com/vapor-sdk/util"
+)
+
+// testWitnessBlock returns the raw header of a block signed by the nodes of
+// signers, nil signers leave their position empty
+func testWitnessBlock(t *testing.T, signers []*chainkd.XPrv) string {
+	header := &types.BlockHeader{
+		Version:           1,
+		Height:            100,
+		PreviousBlockHash: bc.Hash{V0: 1},
+		Timestamp:         1577836800000,
+	}
+
+	hash := header.Hash()
+	for i, xprv := range signers {
+		if xprv != nil {
+			header.Set(uint64(i), xprv.Sign(hash.Bytes()))
+		}
+	}
+
+	rawHeader, err := header.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(rawHeader)
+}
+
+func TestVaporVerifyBlockWitness(t *testing.T) {
+	var xprvs []*chainkd.XPrv
+	var xpubs []string
+	for i := 0; i < 4; i++ {
+		xprv := chainkd.RootXPrv([]byte(fmt.Sprintf("node%d", i)))
+		xprvs = append(xprvs, &xprv)
+		xpubs = append(xpubs, xprv.XPub().String())
+	}
+	other := chainkd.RootXPrv([]byte("other"))
+
+	cases := []struct {
+		desc       string
+		signers    []*chainkd.XPrv
+		threshold  int
+		statuses   []string
+		signCount  int
+		wantThresh int
+		reached    bool
+	}{
+		{
+			desc:       "all nodes signed",
+			signers:    xprvs,
+			statuses:   []string{util.SignatureValid, util.SignatureValid, util.SignatureValid, util.SignatureValid},
+			signCount:  4,
+			wantThresh: 3,
+			reached:    true,
+		},
+		{
+			desc:       "missing and invalid signatures",
+			signers:    []*chainkd.XPrv{xprvs[0], nil, &other, xprvs[3]},
+			statuses:   []string{util.SignatureValid, util.SignatureMissing, util.SignatureInvalid, util.SignatureValid},
+			signCount:  2,
+			wantThresh: 3,
+		},
+		{
+			desc:       "given threshold",
+			signers:    []*chainkd.XPrv{xprvs[0], nil, &other, xprvs[3]},
+			threshold:  2,
+			statuses:   []string{util.SignatureValid, util.SignatureMissing, util.SignatureInvalid, util.SignatureValid},
+			signCount:  2,
+			wantThresh: 2,
+			reached:    true,
+		},
+		{
+			desc:       "witness of the nodes in another order",
+			signers:    []*chainkd.XPrv{xprvs[1], xprvs[0], xprvs[2]},
+			statuses:   []string{util.SignatureInvalid, util.SignatureInvalid, util.SignatureValid, util.SignatureMissing},
+			signCount:  1,
+			wantThresh: 3,
+		},
+	}
+
+	for _, c := range cases {
+		req := &util.BlockWitnessRequest{RawBlock: testWitnessBlock(t, c.signers), XPubs: xpubs, Threshold: c.threshold}
+		jsonRequest, err := json.Marshal(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		jsonResult, err := VaporVerifyBlockWitness(jsonRequest)
+		if err != nil {
+			t.Errorf("%s: %v", c.desc, err)
+			continue
+		}
+
+		result := &util.BlockWitnessResult{}
+		if err := json.Unmarshal(jsonResult, result); err != nil {
+			t.Fatal(err)
+		}
+
+		if result.SignCount != c.signCount || result.Threshold != c.wantThresh || result.ThresholdReached != c.reached {
+			t.Errorf("%s: got %d signatures threshold %d reached %t, want %d threshold %d reached %t", c.desc, result.SignCount, result.Threshold, result.ThresholdReached, c.signCount, c.wantThresh, c.reached)
+		}
+		if len(result.Signatures) != len(c.statuses) {
+			t.Errorf("%s: got %d signatures, want %d", c.desc, len(result.Signatures), len(c.statuses))
+			continue
+		}
+		for i, sig := range result.Signatures {
+			if sig.Order != i || sig.XPub != xpubs[i] || sig.Status != c.statuses[i] {
+				t.Errorf("%s: signature %d: got order %d xpub %s status %s, want status %s", c.desc, i, sig.Order, sig.XPub, sig.Status, c.statuses[i])
+			}
+		}
+	}
+}
+
+func TestVaporVerifyBlockWitnessError(t *testing.T) {
+	xprv := chainkd.RootXPrv([]byte("node0"))
+	xpub := xprv.XPub().String()
+	rawBlock := testWitnessBlock(t, []*chainkd.XPrv{&xprv})
+
+	cases := []struct {
+		desc    string
+		request string
+		kind    util.ErrorKind
+	}{
+		{
+			desc:    "invalid json",
+			request: `{"raw_block": 1}`,
+			kind:    util.ErrKindInvalidWitness,
+		},
+		{
+			desc:    "no consensus node",
+			request: fmt.Sprintf(`{"raw_block": %q}`, rawBlock),
+			kind:    util.ErrKindInvalidWitness,
+		},
+		{
+			desc:    "duplicate consensus node",
+			request: fmt.Sprintf(`{"raw_block": %q, "xpubs": [%q, %q]}`, rawBlock, xpub, xpub),
+			kind:    util.ErrKindInvalidWitness,
+		},
+		{
+			desc:    "threshold over the nodes",
+			request: fmt.Sprintf(`{"raw_block": %q, "xpubs": [%q], "threshold": 2}`, rawBlock, xpub),
+			kind:    util.ErrKindInvalidWitness,
+		},
+		{
+			desc:    "invalid xpub",
+			request: fmt.Sprintf(`{"raw_block": %q, "xpubs": ["00"]}`, rawBlock),
+			kind:    util.ErrKindInvalidKey,
+		},
+		{
+			desc:    "invalid block",
+			request: fmt.Sprintf(`{"raw_block": "03", "xpubs": [%q]}`, xpub),
+			kind:    util.ErrKindMalformedBlock,
+		},
+	}
+
+	for _, c := range cases {
+		_, err := VaporVerifyBlockWitness([]byte(c.request))
+		if decodeErr, ok := err.(*util.DecodeError); !ok || decodeErr.Kind != c.kind {
+			t.Errorf("%s: got error %v, want kind %s", c.desc, err, c.kind)
+		}
+	}
+}